@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ApplyWithRollback renders input through r, validates the result, and
+// only reloads the router if validation passes. If validation fails, the
+// previous contents of input.OutputDirectory are restored before returning
+// the validation error, so a bad render never reaches the live config.
+func ApplyWithRollback(ctx context.Context, r Renderer, input RenderInput) error {
+	backup, err := snapshotDirectory(input.OutputDirectory)
+	if err != nil {
+		return fmt.Errorf("snapshot %s before render: %v", input.OutputDirectory, err)
+	}
+
+	if err := r.Render(input); err != nil {
+		return fmt.Errorf("render: %v", err)
+	}
+
+	if err := r.Validate(input.OutputDirectory); err != nil {
+		if restoreErr := restoreDirectory(input.OutputDirectory, backup); restoreErr != nil {
+			return fmt.Errorf("validate: %v (additionally failed to roll back %s: %v)", err, input.OutputDirectory, restoreErr)
+		}
+		return fmt.Errorf("validate: %v; rolled back to previous config", err)
+	}
+
+	return r.Reload(ctx)
+}
+
+// snapshotDirectory reads every regular file directly inside dir into memory
+func snapshotDirectory(dir string) (map[string][]byte, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return map[string][]byte{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = content
+	}
+
+	return files, nil
+}
+
+// restoreDirectory writes backup's contents back to dir, removing any file
+// that Render wrote but wasn't present in backup
+func restoreDirectory(dir string, backup map[string][]byte) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, kept := backup[entry.Name()]; !kept {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, content := range backup {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+// Package backend abstracts over router implementations (BIRD, OpenBGPD,
+// FRR) so bcg can generate and apply config for more than just BIRD. Each
+// Renderer ships its own template set under templates/<name>/ and knows
+// how to validate and reload its own router.
+package backend
+
+import (
+	"context"
+
+	"github.com/natesales/bcg/config"
+	"github.com/natesales/bcg/verify"
+)
+
+// RenderInput bundles everything a Renderer needs to generate config, so
+// adding a field doesn't change every implementation's signature
+type RenderInput struct {
+	Config             config.Config
+	Statuses           map[string]verify.PeerStatus // Verification results, keyed by peer name
+	TemplatesDirectory string
+	OutputDirectory    string
+	RoaRoutes4         string // BIRD static roa4 route statements; only honored by the bird backend
+	RoaRoutes6         string // BIRD static roa6 route statements; only honored by the bird backend
+}
+
+// Renderer generates router config from a RenderInput, validates it with
+// the backend's own syntax checker, and reloads the running router to
+// pick up the change.
+type Renderer interface {
+	// Name returns the backend's identifier, matching Config.Backend
+	Name() string
+
+	// Render writes the backend's config files to input.OutputDirectory
+	Render(input RenderInput) error
+
+	// Validate runs the backend's syntax checker against the files
+	// written by Render, returning a non-nil error if they're invalid
+	Validate(outputDirectory string) error
+
+	// Reload tells the running router to pick up the new config
+	Reload(ctx context.Context) error
+}
+
+// New constructs the Renderer registered for name, or nil if none matches.
+// birdSocket is only used by the bird backend; other backends ignore it.
+func New(name string, birdSocket string) Renderer {
+	switch name {
+	case "", "bird":
+		return &Bird{Socket: birdSocket}
+	case "openbgpd":
+		return &OpenBGPD{}
+	case "frr":
+		return &FRR{}
+	default:
+		return nil
+	}
+}
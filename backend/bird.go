@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/natesales/bcg/bird"
+	"github.com/natesales/bcg/render"
+)
+
+// Bird renders config for the BIRD 2 routing daemon and applies it over its unix control socket
+type Bird struct {
+	Socket string
+}
+
+// Name returns this backend's identifier
+func (b *Bird) Name() string { return "bird" }
+
+// Render writes bird.conf and one file per peer, using templates/bird/
+func (b *Bird) Render(input RenderInput) error {
+	cfg := input.Config
+	templatesDirectory := path.Join(input.TemplatesDirectory, "bird")
+
+	var originIpv4, originIpv6 []string
+	for _, prefix := range cfg.Prefixes {
+		if strings.Contains(prefix, ":") {
+			originIpv6 = append(originIpv6, prefix)
+		} else {
+			originIpv4 = append(originIpv4, prefix)
+		}
+	}
+
+	if err := render.Global(templatesDirectory, input.OutputDirectory, "bird.conf", &render.GlobalTemplate{
+		Config:        cfg,
+		OriginString4: bird.BuildSet(originIpv4),
+		OriginString6: bird.BuildSet(originIpv6),
+		OriginList4:   originIpv4,
+		OriginList6:   originIpv6,
+		RoaRoutes4:    input.RoaRoutes4,
+		RoaRoutes6:    input.RoaRoutes6,
+	}); err != nil {
+		return fmt.Errorf("render global: %v", err)
+	}
+
+	for peerName, peerData := range cfg.Peers {
+		var pfxFilterString4, pfxFilterString6 string
+		if peerData.ImportPolicy == "cone" {
+			pfxFilterString4 = bird.BuildSet(peerData.PfxFilter4)
+			pfxFilterString6 = bird.BuildSet(peerData.PfxFilter6)
+		}
+
+		fileName := "AS" + strconv.Itoa(int(peerData.Asn)) + "_" + render.Normalize(peerName) + ".conf"
+		if err := render.Peer(templatesDirectory, input.OutputDirectory, fileName, &render.PeerTemplate{
+			Peer:             *peerData,
+			Name:             peerName,
+			PfxFilterString4: pfxFilterString4,
+			PfxFilterString6: pfxFilterString6,
+			Global:           cfg,
+			Status:           input.Statuses[peerName],
+		}); err != nil {
+			return fmt.Errorf("render peer %s: %v", peerName, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate runs BIRD's own parser against the generated files via
+// "configure check" on the control socket, without applying them
+func (b *Bird) Validate(outputDirectory string) error {
+	response, err := bird.RunCommandOutput(b.Socket, "configure check")
+	if err != nil {
+		return err
+	}
+	if strings.Contains(strings.ToLower(response), "error") {
+		return fmt.Errorf("BIRD config check failed: %s", strings.TrimSpace(response))
+	}
+	return nil
+}
+
+// Reload tells BIRD to apply the generated config
+func (b *Bird) Reload(ctx context.Context) error {
+	return bird.RunCommand(b.Socket, "configure")
+}
@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/natesales/bcg/render"
+)
+
+// frrConfigFile is where FRR expects its BGP config; bcg still renders to
+// input.OutputDirectory and lets the operator symlink or copy it there, in
+// keeping with how the bird and openbgpd backends are deployed
+const frrConfigFile = "bgpd.conf"
+
+// FRR renders config for FRRouting's bgpd and applies it via vtysh
+type FRR struct {
+	configPath string // set by Render, used by Reload to point vtysh at the file we just wrote
+}
+
+// Name returns this backend's identifier
+func (f *FRR) Name() string { return "frr" }
+
+// buildPrefixList formats a prefix list using FRR's "ip prefix-list" syntax
+func buildPrefixList(name string, filter []string) string {
+	var lines []string
+	for _, prefix := range filter {
+		lines = append(lines, fmt.Sprintf("ip prefix-list %s permit %s", name, prefix))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Render writes bgpd.conf and one file per peer, using templates/frr/
+func (f *FRR) Render(input RenderInput) error {
+	cfg := input.Config
+	templatesDirectory := path.Join(input.TemplatesDirectory, "frr")
+	f.configPath = path.Join(input.OutputDirectory, frrConfigFile)
+
+	var originIpv4, originIpv6 []string
+	for _, prefix := range cfg.Prefixes {
+		if strings.Contains(prefix, ":") {
+			originIpv6 = append(originIpv6, prefix)
+		} else {
+			originIpv4 = append(originIpv4, prefix)
+		}
+	}
+
+	if err := render.Global(templatesDirectory, input.OutputDirectory, frrConfigFile, &render.GlobalTemplate{
+		Config:        cfg,
+		OriginString4: buildPrefixList("originate4", originIpv4),
+		OriginString6: buildPrefixList("originate6", originIpv6),
+		OriginList4:   originIpv4,
+		OriginList6:   originIpv6,
+	}); err != nil {
+		return fmt.Errorf("render global: %v", err)
+	}
+
+	for peerName, peerData := range cfg.Peers {
+		var pfxFilterString4, pfxFilterString6 string
+		if peerData.ImportPolicy == "cone" {
+			pfxFilterString4 = buildPrefixList(render.Normalize(peerName)+"4", peerData.PfxFilter4)
+			pfxFilterString6 = buildPrefixList(render.Normalize(peerName)+"6", peerData.PfxFilter6)
+		}
+
+		fileName := "AS" + strconv.Itoa(int(peerData.Asn)) + "_" + render.Normalize(peerName) + ".conf"
+		if err := render.Peer(templatesDirectory, input.OutputDirectory, fileName, &render.PeerTemplate{
+			Peer:             *peerData,
+			Name:             peerName,
+			PfxFilterString4: pfxFilterString4,
+			PfxFilterString6: pfxFilterString6,
+			Global:           cfg,
+			Status:           input.Statuses[peerName],
+		}); err != nil {
+			return fmt.Errorf("render peer %s: %v", peerName, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate runs vtysh's dry-run config check against the generated config
+func (f *FRR) Validate(outputDirectory string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("vtysh", "-C", "-f", path.Join(outputDirectory, frrConfigFile))
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("vtysh config check failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Reload loads the generated config into the running bgpd via vtysh
+func (f *FRR) Reload(ctx context.Context) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "vtysh", "-f", f.configPath)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("vtysh reload failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
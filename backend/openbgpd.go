@@ -0,0 +1,99 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/natesales/bcg/render"
+)
+
+// OpenBGPD renders config for OpenBSD's bgpd and applies it via bgpctl
+type OpenBGPD struct{}
+
+// Name returns this backend's identifier
+func (o *OpenBGPD) Name() string { return "openbgpd" }
+
+// buildPrefixSet formats a prefix list using bgpd.conf's "prefix-set" syntax
+func buildPrefixSet(filter []string) string {
+	var lines []string
+	for _, prefix := range filter {
+		lines = append(lines, fmt.Sprintf("\tprefix %s", prefix))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Render writes bgpd.conf and one file per peer, using templates/openbgpd/
+func (o *OpenBGPD) Render(input RenderInput) error {
+	cfg := input.Config
+	templatesDirectory := path.Join(input.TemplatesDirectory, "openbgpd")
+
+	var originIpv4, originIpv6 []string
+	for _, prefix := range cfg.Prefixes {
+		if strings.Contains(prefix, ":") {
+			originIpv6 = append(originIpv6, prefix)
+		} else {
+			originIpv4 = append(originIpv4, prefix)
+		}
+	}
+
+	if err := render.Global(templatesDirectory, input.OutputDirectory, "bgpd.conf", &render.GlobalTemplate{
+		Config:        cfg,
+		OriginString4: buildPrefixSet(originIpv4),
+		OriginString6: buildPrefixSet(originIpv6),
+		OriginList4:   originIpv4,
+		OriginList6:   originIpv6,
+	}); err != nil {
+		return fmt.Errorf("render global: %v", err)
+	}
+
+	for peerName, peerData := range cfg.Peers {
+		var pfxFilterString4, pfxFilterString6 string
+		if peerData.ImportPolicy == "cone" {
+			pfxFilterString4 = buildPrefixSet(peerData.PfxFilter4)
+			pfxFilterString6 = buildPrefixSet(peerData.PfxFilter6)
+		}
+
+		fileName := "AS" + strconv.Itoa(int(peerData.Asn)) + "_" + render.Normalize(peerName) + ".conf"
+		if err := render.Peer(templatesDirectory, input.OutputDirectory, fileName, &render.PeerTemplate{
+			Peer:             *peerData,
+			Name:             peerName,
+			PfxFilterString4: pfxFilterString4,
+			PfxFilterString6: pfxFilterString6,
+			Global:           cfg,
+			Status:           input.Statuses[peerName],
+		}); err != nil {
+			return fmt.Errorf("render peer %s: %v", peerName, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate runs bgpd's own parse-only check against the generated config
+func (o *OpenBGPD) Validate(outputDirectory string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("bgpd", "-n", "-f", path.Join(outputDirectory, "bgpd.conf"))
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("bgpd config check failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Reload tells bgpd to reload its config
+func (o *OpenBGPD) Reload(ctx context.Context) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "bgpctl", "reload")
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("bgpctl reload failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
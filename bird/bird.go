@@ -0,0 +1,79 @@
+// Package bird interacts with the BIRD routing daemon
+package bird
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// readNoBuffer is a nonbuffered io Reader
+func readNoBuffer(reader io.Reader) string {
+	buf := make([]byte, 1024)
+	n, err := reader.Read(buf[:])
+	if err != nil {
+		log.Fatalf("BIRD read error: %v", err)
+	}
+
+	return string(buf[:n])
+}
+
+// RunCommand sends a command to the BIRD control socket
+func RunCommand(socket string, command string) error {
+	log.Println("Connecting to BIRD socket")
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return fmt.Errorf("BIRD socket connect: %v", err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer conn.Close()
+
+	log.Println("Connected to BIRD socket")
+	log.Printf("BIRD init response: %s", readNoBuffer(conn))
+
+	log.Printf("Sending BIRD command: %s", command)
+	_, err = conn.Write([]byte(strings.Trim(command, "\n") + "\n"))
+	if err != nil {
+		return fmt.Errorf("BIRD write error: %v", err)
+	}
+	log.Printf("Sent BIRD command: %s", command)
+
+	log.Printf("BIRD response: %s", readNoBuffer(conn))
+	return nil
+}
+
+// RunCommandOutput sends a command to the BIRD control socket and returns
+// its response, for callers that need to inspect the result (e.g. checking
+// "configure check" for a syntax error) rather than just firing-and-forgetting it
+func RunCommandOutput(socket string, command string) (string, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return "", fmt.Errorf("BIRD socket connect: %v", err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer conn.Close()
+
+	readNoBuffer(conn) // discard the welcome banner
+
+	if _, err := conn.Write([]byte(strings.Trim(command, "\n") + "\n")); err != nil {
+		return "", fmt.Errorf("BIRD write error: %v", err)
+	}
+
+	return readNoBuffer(conn), nil
+}
+
+// BuildSet builds a formatted BIRD prefix list
+func BuildSet(filter []string) string {
+	output := ""
+	for i, prefix := range filter {
+		output += "    " + prefix
+		if i != len(filter)-1 {
+			output += ",\n"
+		}
+	}
+
+	return output
+}
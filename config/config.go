@@ -0,0 +1,217 @@
+// Package config defines the bcg configuration schema and loads it from
+// YAML, TOML, or JSON files.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// Peer contains all information specific to a single peer network
+type Peer struct {
+	Asn            uint32   `yaml:"asn" toml:"ASN" json:"asn"`
+	AsSet          string   `yaml:"as-set" toml:"AS-Set" json:"as-set"`
+	MaxPfx4        int64    `yaml:"maxpfx4" yaml:"MaxPfx4" json:"maxpfx4"`
+	MaxPfx6        int64    `yaml:"maxpfx6" yaml:"MaxPfx6" json:"maxpfx6"`
+	PfxLimitAction string   `yaml:"pfxlimitaction" yaml:"PfxLimitAction" json:"pfxlimitaction"`
+	PfxFilter4     []string `yaml:"pfxfilter4" yaml:"PfxFilter4" json:"PfxFilter4"`
+	PfxFilter6     []string `yaml:"pfxfilter6" yaml:"PfxFilter6" json:"PfxFilter6"`
+	ImportPolicy   string   `yaml:"import" toml:"ImportPolicy" json:"import"`
+	ExportPolicy   string   `yaml:"export" toml:"ExportPolicy" json:"export"`
+	LocalPref      uint32   `yaml:"localpref" toml:"LocalPref" json:"localpref"`
+	NeighborIps    []string `yaml:"neighbors" toml:"Neighbors" json:"neighbors"`
+	Secret         string   `yaml:"secret" toml:"Secret" json:"secret,omitempty"` // MD5/TCP-AO session key, empty if unauthenticated
+	Multihop       bool     `yaml:"multihop" toml:"Multihop" json:"multihop"`
+	Passive        bool     `yaml:"passive" toml:"Passive" json:"passive"`
+	Disabled       bool     `yaml:"disabled" toml:"Disabled" json:"disabled"`
+	AutoMaxPfx     bool     `yaml:"automaxpfx" toml:"AutoMaxPfx" json:"automaxpfx"`
+	AutoPfxFilter  bool     `yaml:"autopfxfilter" toml:"AutoPfxFilter" json:"autopfxfilter"`
+	PreImport      string   `yaml:"preimport" toml:"PreImport" json:"preimport"`
+	PreExport      string   `yaml:"preexport" toml:"PreExport" json:"preexport"`
+	Prepends       uint     `yaml:"prepends" toml:"Prepends" json:"prepends"`
+	QueryTime      string   `yaml:"-" toml:"-" json:"-"`
+}
+
+// Config contains global configuration about this router and BCG instance
+type Config struct {
+	Asn       uint32           `yaml:"asn" toml:"ASN" json:"asn"`
+	RouterId  string           `yaml:"router-id" toml:"Router-ID" json:"router-id"`
+	Prefixes  []string         `yaml:"prefixes" toml:"Prefixes" json:"prefixes"`
+	Peers     map[string]*Peer `yaml:"peers" toml:"Peers" json:"peers"`
+	IrrDb     string           `yaml:"irrdb" toml:"IRRDB" json:"irrdb"`
+	RtrServer string           `yaml:"rtrserver" toml:"RPKIServer" json:"rtrserver"`
+	Backend   string           `yaml:"backend" toml:"Backend" json:"backend"`
+}
+
+// Load reads and unmarshals a configuration file, selecting the format
+// (YAML, TOML, or JSON) based on its file extension
+func Load(filename string) (*Config, error) {
+	configFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", filename, err)
+	}
+
+	var config Config
+
+	splitFilename := strings.Split(filename, ".")
+	switch extension := splitFilename[len(splitFilename)-1]; extension {
+	case "yaml", "yml":
+		log.Info("Using YAML configuration format")
+		err = yaml.Unmarshal(configFile, &config)
+	case "toml":
+		log.Info("Using TOML configuration format")
+		err = toml.Unmarshal(configFile, &config)
+	case "json":
+		log.Info("Using JSON configuration format")
+		err = json.Unmarshal(configFile, &config)
+	default:
+		return nil, fmt.Errorf("files with extension '%s' are not supported. (Acceptable values are yaml, toml, json)", extension)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %v", filename, err)
+	}
+
+	// Set default IRRDB
+	if config.IrrDb == "" {
+		config.IrrDb = "rr.ntt.net"
+	}
+	log.Infof("Using IRRDB server %s", config.IrrDb)
+
+	// Set default RTR server
+	if config.RtrServer == "" {
+		config.RtrServer = "127.0.0.1"
+	}
+	log.Infof("Using RTR server %s", config.RtrServer)
+
+	// Set default backend
+	if config.Backend == "" {
+		config.Backend = "bird"
+	}
+	log.Infof("Using %s backend", config.Backend)
+
+	return &config, nil
+}
+
+// Save marshals a Config back to filename in the format selected by its
+// extension, for callers (e.g. the peering handshake) that mutate a config
+// loaded with Load and need to persist the result
+func Save(filename string, c *Config) error {
+	splitFilename := strings.Split(filename, ".")
+
+	var encoded []byte
+	var err error
+	switch extension := splitFilename[len(splitFilename)-1]; extension {
+	case "yaml", "yml":
+		encoded, err = yaml.Marshal(c)
+	case "toml":
+		encoded, err = toml.Marshal(c)
+	case "json":
+		encoded, err = json.MarshalIndent(c, "", "  ")
+	default:
+		return fmt.Errorf("files with extension '%s' are not supported. (Acceptable values are yaml, toml, json)", extension)
+	}
+	if err != nil {
+		return fmt.Errorf("marshal config: %v", err)
+	}
+
+	return ioutil.WriteFile(filename, encoded, 0644)
+}
+
+// Validate checks a loaded Config for structural errors, returning the
+// first one it finds
+func (c *Config) Validate() error {
+	// Validate Router ID in dotted quad format
+	if net.ParseIP(c.RouterId).To4() == nil {
+		return fmt.Errorf("router ID %s is not in valid dotted quad notation", c.RouterId)
+	}
+
+	// Validate backend
+	if !(c.Backend == "bird" || c.Backend == "openbgpd" || c.Backend == "frr") {
+		return fmt.Errorf("backend %s is not supported. Acceptable values are bird, openbgpd, and frr", c.Backend)
+	}
+
+	// Validate CIDR notation of originated prefixes
+	for _, addr := range c.Prefixes {
+		if _, _, err := net.ParseCIDR(addr); err != nil {
+			return fmt.Errorf("%s is not a valid IPv4 or IPv6 prefix in CIDR notation", addr)
+		}
+	}
+
+	for peerName, peerData := range c.Peers {
+		if err := ValidatePeer(peerName, peerData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidatePeer checks a single peer for structural errors and fills in its
+// defaults (pfxlimitaction, max-prefix for upstream sessions, local pref),
+// returning the first error it finds. It's factored out of Validate so
+// callers that create or update one peer at a time (e.g. the daemon's
+// peer API) can apply the same checks Validate runs for the static-file path.
+func ValidatePeer(peerName string, peerData *Peer) error {
+	// Set default pfxlimitaction
+	if peerData.PfxLimitAction == "" {
+		peerData.PfxLimitAction = "disable"
+	} else if !(peerData.PfxLimitAction == "disable" || peerData.PfxLimitAction == "restart" || peerData.PfxLimitAction == "block" || peerData.PfxLimitAction == "warn") {
+		return fmt.Errorf("peer %s has an invalid pfxlimitaction. Acceptable values are warn, block, restart, and disable", peerName)
+	}
+
+	// If no AS-Set is defined and the import policy requires it
+	if !peerData.AutoPfxFilter && peerData.ImportPolicy == "cone" {
+		if peerData.AsSet == "" {
+			return fmt.Errorf("peer %s has a cone filtered import policy and has no AS-Set defined. Set autopfxfilter to true to enable automatic IRRDB imports", peerName)
+		} else if !strings.HasPrefix(peerData.AsSet, "AS") {
+			log.Warnf("AS-Set for %s (as-set: %s) doesn't start with 'AS' and might be invalid", peerName, peerData.AsSet)
+		}
+
+		if peerData.ImportPolicy != "none" && (len(peerData.PfxFilter4) == 0 || len(peerData.PfxFilter6) == 0) {
+			return fmt.Errorf("peer %s has a cone filtered import policy and has no prefix filters defined. Set autopfxfilter to true to enable automatic IRRDB imports", peerName)
+		}
+	}
+
+	// Open up prefix limits if upstream
+	if peerData.ImportPolicy == "any" {
+		log.Warnf("Peer %s has no max-prefix limits configured and is an upstream session. Setting limits to 1M IPv4 and 100k IPv6", peerName)
+		peerData.MaxPfx4 = 1000000
+		peerData.MaxPfx6 = 100000
+	} else if peerData.ImportPolicy == "cone" {
+		if !peerData.AutoMaxPfx && (peerData.MaxPfx4 == 0 || peerData.MaxPfx6 == 0) {
+			return fmt.Errorf("peer %s has no max-prefix limits configured. Set automaxpfx to true to pull from PeeringDB", peerName)
+		}
+	}
+
+	// Set default local pref
+	if peerData.LocalPref == 0 {
+		peerData.LocalPref = 100
+	}
+
+	// Validate import policy
+	if !(peerData.ImportPolicy == "any" || peerData.ImportPolicy == "cone" || peerData.ImportPolicy == "none") {
+		return fmt.Errorf("peer %s has an invalid import policy. Acceptable values are 'any', 'cone', or 'none'", peerName)
+	}
+
+	// Validate export policy
+	if !(peerData.ExportPolicy == "any" || peerData.ExportPolicy == "cone" || peerData.ExportPolicy == "none") {
+		return fmt.Errorf("peer %s has an invalid export policy. Acceptable values are 'any', 'cone', or 'none'", peerName)
+	}
+
+	// Validate neighbor IPs
+	for _, addr := range peerData.NeighborIps {
+		if net.ParseIP(addr) == nil {
+			return fmt.Errorf("neighbor address of peer %s (addr: %s) is not a valid IPv4 or IPv6 address", peerName, addr)
+		}
+	}
+
+	log.Infof("Policy for AS%d: import %s, export %s", peerData.Asn, peerData.ImportPolicy, peerData.ExportPolicy)
+	return nil
+}
@@ -0,0 +1,41 @@
+package daemon
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// Audit records every mutation the daemon makes so operators can trace who
+// changed what, structured for easy ingestion by a log pipeline.
+type Audit struct {
+	logger *log.Logger
+}
+
+// NewAudit creates an Audit logger that writes structured JSON records to path
+func NewAudit(path string) (*Audit, error) {
+	logger := log.New()
+	logger.SetFormatter(&log.JSONFormatter{})
+
+	file, err := openAppend(path)
+	if err != nil {
+		return nil, err
+	}
+	logger.SetOutput(file)
+
+	return &Audit{logger: logger}, nil
+}
+
+// Record logs a single mutation performed by actor against peerName
+func (a *Audit) Record(actor, action, peerName string, err error) {
+	entry := a.logger.WithFields(log.Fields{
+		"actor":  actor,
+		"action": action,
+		"peer":   peerName,
+	})
+
+	if err != nil {
+		entry.WithError(err).Warn("mutation failed")
+		return
+	}
+
+	entry.Info("mutation applied")
+}
@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/natesales/bcg/config"
+)
+
+// peersBucket is the BoltDB bucket peers are stored under, keyed by peer name
+var peersBucket = []byte("peers")
+
+// Store persists peer configuration for the daemon. BoltDB is the default
+// backend; a Postgres-backed Store can be substituted for multi-instance
+// deployments.
+type Store interface {
+	// ListPeers returns every persisted peer, keyed by name
+	ListPeers() (map[string]*config.Peer, error)
+	// GetPeer returns a single persisted peer, and whether it was found
+	GetPeer(name string) (*config.Peer, bool, error)
+	// SavePeer creates or replaces the peer with the given name
+	SavePeer(name string, peer *config.Peer) error
+	// DeletePeer removes the peer with the given name
+	DeletePeer(name string) error
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// BoltStore is the default Store backend, suitable for single-instance bcg daemons
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB-backed Store at path
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open BoltDB at %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(peersBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create peers bucket: %v", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// ListPeers implements Store
+func (s *BoltStore) ListPeers() (map[string]*config.Peer, error) {
+	peers := map[string]*config.Peer{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(peersBucket).ForEach(func(k, v []byte) error {
+			var peer config.Peer
+			if err := json.Unmarshal(v, &peer); err != nil {
+				return fmt.Errorf("unmarshal peer %s: %v", k, err)
+			}
+			peers[string(k)] = &peer
+			return nil
+		})
+	})
+
+	return peers, err
+}
+
+// GetPeer implements Store
+func (s *BoltStore) GetPeer(name string) (*config.Peer, bool, error) {
+	var peer config.Peer
+	found := false
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(peersBucket).Get([]byte(name))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &peer)
+	})
+	if err != nil || !found {
+		return nil, found, err
+	}
+
+	return &peer, true, nil
+}
+
+// SavePeer implements Store
+func (s *BoltStore) SavePeer(name string, peer *config.Peer) error {
+	encoded, err := json.Marshal(peer)
+	if err != nil {
+		return fmt.Errorf("marshal peer %s: %v", name, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(peersBucket).Put([]byte(name), encoded)
+	})
+}
+
+// DeletePeer implements Store
+func (s *BoltStore) DeletePeer(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(peersBucket).Delete([]byte(name))
+	})
+}
+
+// Close implements Store
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
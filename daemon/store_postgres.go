@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	// Postgres driver, registered for database/sql
+	_ "github.com/lib/pq"
+
+	"github.com/natesales/bcg/config"
+)
+
+// PostgresStore is an optional Store backend for deployments running
+// multiple bcg daemons against shared state
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore opens a Postgres-backed Store using a "postgres://" DSN
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("ping postgres: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS bcg_peers (name TEXT PRIMARY KEY, data JSONB NOT NULL)`)
+	if err != nil {
+		return nil, fmt.Errorf("create bcg_peers table: %v", err)
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// ListPeers implements Store
+func (s *PostgresStore) ListPeers() (map[string]*config.Peer, error) {
+	rows, err := s.db.Query(`SELECT name, data FROM bcg_peers`)
+	if err != nil {
+		return nil, fmt.Errorf("query bcg_peers: %v", err)
+	}
+	defer rows.Close()
+
+	peers := map[string]*config.Peer{}
+	for rows.Next() {
+		var name string
+		var data []byte
+		if err := rows.Scan(&name, &data); err != nil {
+			return nil, fmt.Errorf("scan bcg_peers row: %v", err)
+		}
+
+		var peer config.Peer
+		if err := json.Unmarshal(data, &peer); err != nil {
+			return nil, fmt.Errorf("unmarshal peer %s: %v", name, err)
+		}
+		peers[name] = &peer
+	}
+
+	return peers, rows.Err()
+}
+
+// GetPeer implements Store
+func (s *PostgresStore) GetPeer(name string) (*config.Peer, bool, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM bcg_peers WHERE name = $1`, name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("query peer %s: %v", name, err)
+	}
+
+	var peer config.Peer
+	if err := json.Unmarshal(data, &peer); err != nil {
+		return nil, false, fmt.Errorf("unmarshal peer %s: %v", name, err)
+	}
+
+	return &peer, true, nil
+}
+
+// SavePeer implements Store
+func (s *PostgresStore) SavePeer(name string, peer *config.Peer) error {
+	encoded, err := json.Marshal(peer)
+	if err != nil {
+		return fmt.Errorf("marshal peer %s: %v", name, err)
+	}
+
+	_, err = s.db.Exec(`INSERT INTO bcg_peers (name, data) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data`, name, encoded)
+	return err
+}
+
+// DeletePeer implements Store
+func (s *PostgresStore) DeletePeer(name string) error {
+	_, err := s.db.Exec(`DELETE FROM bcg_peers WHERE name = $1`, name)
+	return err
+}
+
+// Close implements Store
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
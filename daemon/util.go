@@ -0,0 +1,8 @@
+package daemon
+
+import "os"
+
+// openAppend opens path for appending, creating it if necessary
+func openAppend(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
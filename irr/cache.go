@@ -0,0 +1,73 @@
+package irr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache is an on-disk cache of IRR prefix expansions, keyed by
+// (as-set, family, irrdb) so entries from different IRR servers never collide
+type Cache struct {
+	Dir string
+	TTL time.Duration
+}
+
+// NewCache creates a Cache rooted at dir, creating it if necessary
+func NewCache(dir string, ttl time.Duration) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: dir, TTL: ttl}, nil
+}
+
+type cacheEntry struct {
+	StoredAt time.Time `json:"stored_at"`
+	Prefixes []string  `json:"prefixes"`
+}
+
+// Get returns the cached prefixes for (asSet, family, irrdb), and whether a
+// live (not yet expired) entry was found
+func (c *Cache) Get(asSet string, family uint8, irrdb string) ([]string, bool) {
+	data, err := ioutil.ReadFile(c.path(asSet, family, irrdb))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+
+	if time.Since(entry.StoredAt) > c.TTL {
+		return nil, false
+	}
+
+	return entry.Prefixes, true
+}
+
+// Set persists prefixes for (asSet, family, irrdb), overwriting any existing entry
+func (c *Cache) Set(asSet string, family uint8, irrdb string, prefixes []string) error {
+	encoded, err := json.Marshal(cacheEntry{StoredAt: time.Now(), Prefixes: prefixes})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path(asSet, family, irrdb), encoded, 0644)
+}
+
+func (c *Cache) path(asSet string, family uint8, irrdb string) string {
+	hash := sha256.Sum256([]byte(irrdb + "|" + asSet))
+	return filepath.Join(c.Dir, hex.EncodeToString(hash[:])+"."+familySuffix(family)+".json")
+}
+
+func familySuffix(family uint8) string {
+	if family == 6 {
+		return "v6"
+	}
+	return "v4"
+}
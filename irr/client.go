@@ -0,0 +1,171 @@
+// Package irr speaks the IRRd whois protocol directly over TCP/43 to expand
+// AS-Sets and list the prefixes an ASN is a registered origin for, with
+// connection pooling, retries, and an on-disk cache in front of it.
+package irr
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dialTimeout bounds how long establishing a new connection to an irrd may take
+const dialTimeout = 10 * time.Second
+
+// queryTimeout bounds how long a single whois query may take once connected
+const queryTimeout = 15 * time.Second
+
+// Client speaks the IRRd whois protocol against a single server, pooling
+// its persistent connections for reuse across queries
+type Client struct {
+	Address string // host:port, e.g. "rr.ntt.net:43"
+	pool    *connPool
+}
+
+// NewClient creates a Client for irrdb, defaulting to port 43 if none is given
+func NewClient(irrdb string) *Client {
+	address := irrdb
+	if !strings.Contains(address, ":") {
+		address = address + ":43"
+	}
+
+	return &Client{Address: address, pool: newConnPool(address)}
+}
+
+// ExpandAsSet recursively expands an AS-Set (via "!i<set>,1") into its member ASNs
+func (c *Client) ExpandAsSet(asSet string) ([]uint32, error) {
+	response, err := c.query(fmt.Sprintf("!i%s,1", asSet))
+	if err != nil {
+		return nil, fmt.Errorf("expand AS-Set %s: %v", asSet, err)
+	}
+
+	var asns []uint32
+	for _, field := range strings.Fields(response) {
+		field = strings.TrimPrefix(field, "AS")
+		asn, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			continue // not every member of a set expansion is itself an ASN
+		}
+		asns = append(asns, uint32(asn))
+	}
+
+	return asns, nil
+}
+
+// PrefixesForAsn returns the prefixes an ASN is a registered route origin
+// for, via "!gAS<n>" (IPv4) or "!6AS<n>" (IPv6)
+func (c *Client) PrefixesForAsn(asn uint32, family uint8) ([]string, error) {
+	queryType := "g"
+	if family == 6 {
+		queryType = "6"
+	}
+
+	response, err := c.query(fmt.Sprintf("!%sAS%d", queryType, asn))
+	if err != nil {
+		return nil, fmt.Errorf("prefixes for AS%d: %v", asn, err)
+	}
+
+	return strings.Fields(response), nil
+}
+
+// query sends a single "!"-prefixed whois query over a pooled connection
+// and returns its data payload, retrying with exponential backoff on
+// connection failure
+func (c *Client) query(command string) (string, error) {
+	const maxAttempts = 3
+	backoff := 500 * time.Millisecond
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		conn, err := c.pool.get()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		response, err := doQuery(conn, command)
+		if err != nil {
+			lastErr = err
+			conn.Close() //nolint:errcheck // connection is unhealthy, don't return it to the pool
+			continue
+		}
+
+		c.pool.put(conn)
+		return response, nil
+	}
+
+	return "", fmt.Errorf("after %d attempts: %v", maxAttempts, lastErr)
+}
+
+// doQuery sends command and parses an IRRd query response:
+//   "A<len>\n<len bytes of data>\nC\n" on success
+//   "D\n" if the object doesn't exist
+//   "F <message>\n" on error
+func doQuery(conn net.Conn, command string) (string, error) {
+	if err := conn.SetDeadline(time.Now().Add(queryTimeout)); err != nil {
+		return "", err
+	}
+
+	if _, err := fmt.Fprintf(conn, "%s\n", command); err != nil {
+		return "", fmt.Errorf("write query: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	status, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("read status line: %v", err)
+	}
+	status = strings.TrimRight(status, "\r\n")
+
+	switch {
+	case strings.HasPrefix(status, "A"):
+		length, err := strconv.Atoi(status[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid length in status line %q: %v", status, err)
+		}
+
+		data := make([]byte, length)
+		if _, err := readFull(reader, data); err != nil {
+			return "", fmt.Errorf("read %d byte payload: %v", length, err)
+		}
+
+		// Consume the trailing newline and the "C\n" that follows every payload
+		if _, err := reader.ReadString('\n'); err != nil {
+			return "", fmt.Errorf("read payload terminator: %v", err)
+		}
+		if _, err := reader.ReadString('\n'); err != nil {
+			return "", fmt.Errorf("read C terminator: %v", err)
+		}
+
+		return string(data), nil
+
+	case strings.HasPrefix(status, "D"):
+		return "", nil // no matching object; an empty result is not an error
+
+	case strings.HasPrefix(status, "F"):
+		return "", fmt.Errorf("irrd error: %s", strings.TrimPrefix(status, "F "))
+
+	default:
+		return "", fmt.Errorf("unexpected status line: %q", status)
+	}
+}
+
+func readFull(reader *bufio.Reader, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := reader.Read(buf[total:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
@@ -0,0 +1,63 @@
+package irr
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// serveOnce writes response on one end of a net.Pipe and returns the other
+// end for doQuery to read from
+func serveOnce(t *testing.T, response string) net.Conn {
+	t.Helper()
+	client, server := net.Pipe()
+
+	go func() {
+		// Drain the query line the client writes before replying
+		buf := make([]byte, 256)
+		server.Read(buf)               //nolint:errcheck
+		server.Write([]byte(response)) //nolint:errcheck
+		server.Close()
+	}()
+
+	client.SetDeadline(time.Now().Add(5 * time.Second)) //nolint:errcheck
+	return client
+}
+
+func TestDoQuerySuccess(t *testing.T) {
+	conn := serveOnce(t, "A15\nAS65000 AS65001\nC\n")
+	response, err := doQuery(conn, "!iAS-EXAMPLE,1")
+	if err != nil {
+		t.Fatalf("doQuery: %v", err)
+	}
+	if response != "AS65000 AS65001" {
+		t.Fatalf("unexpected response %q", response)
+	}
+}
+
+func TestDoQueryNoObject(t *testing.T) {
+	conn := serveOnce(t, "D\n")
+	response, err := doQuery(conn, "!gAS65000")
+	if err != nil {
+		t.Fatalf("doQuery: %v", err)
+	}
+	if response != "" {
+		t.Fatalf("expected empty response for D, got %q", response)
+	}
+}
+
+func TestDoQueryError(t *testing.T) {
+	conn := serveOnce(t, "F object not found\n")
+	_, err := doQuery(conn, "!gAS65000")
+	if err == nil {
+		t.Fatalf("expected an error for F status")
+	}
+}
+
+func TestDoQueryUnexpectedStatus(t *testing.T) {
+	conn := serveOnce(t, "Z garbage\n")
+	_, err := doQuery(conn, "!gAS65000")
+	if err == nil {
+		t.Fatalf("expected an error for an unrecognized status line")
+	}
+}
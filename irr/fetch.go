@@ -0,0 +1,85 @@
+package irr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultCacheDir is where the on-disk prefix cache lives when callers don't set up their own
+const DefaultCacheDir = "/var/cache/bcg/irr"
+
+// DefaultCacheTTL is how long a cached AS-Set expansion is trusted before being refetched
+const DefaultCacheTTL = time.Hour
+
+// Fetcher expands AS-Sets into prefix filters, pooling a Client per irrdb
+// server and caching results on disk
+type Fetcher struct {
+	cache *Cache
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewFetcher creates a Fetcher backed by cache
+func NewFetcher(cache *Cache) *Fetcher {
+	return &Fetcher{cache: cache, clients: make(map[string]*Client)}
+}
+
+func (f *Fetcher) clientFor(irrdb string) *Client {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if client, ok := f.clients[irrdb]; ok {
+		return client
+	}
+
+	client := NewClient(irrdb)
+	f.clients[irrdb] = client
+	return client
+}
+
+// GetPrefixFilter expands macro's AS-Set into a prefix filter for family,
+// via the cache if a live entry exists, or by querying irrdb directly
+func (f *Fetcher) GetPrefixFilter(macro string, family uint8, irrdb string) ([]string, error) {
+	var asSet string
+	if strings.Contains(macro, "::") {
+		asSet = strings.Split(macro, "::")[1]
+	} else {
+		asSet = macro
+	}
+
+	if f.cache != nil {
+		if cached, ok := f.cache.Get(asSet, family, irrdb); ok {
+			return cached, nil
+		}
+	}
+
+	client := f.clientFor(irrdb)
+
+	asns, err := client.ExpandAsSet(asSet)
+	if err != nil {
+		return nil, fmt.Errorf("expand %s: %v", asSet, err)
+	}
+
+	var prefixes []string
+	for _, asn := range asns {
+		asnPrefixes, err := client.PrefixesForAsn(asn, family)
+		if err != nil {
+			log.Warnf("Prefixes for AS%d (member of %s): %v", asn, asSet, err)
+			continue
+		}
+		prefixes = append(prefixes, asnPrefixes...)
+	}
+
+	if f.cache != nil {
+		if err := f.cache.Set(asSet, family, irrdb, prefixes); err != nil {
+			log.Warnf("Cache write for %s: %v", asSet, err)
+		}
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,51 @@
+package irr
+
+import (
+	"net"
+	"sync"
+)
+
+// poolSize bounds how many idle connections are kept per irrdb address
+const poolSize = 8
+
+// connPool is a small pool of persistent whois connections to a single
+// irrd address, so concurrent fetches don't each pay a fresh TCP+query
+// round trip
+type connPool struct {
+	address string
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newConnPool(address string) *connPool {
+	return &connPool{address: address}
+}
+
+// get returns a pooled connection, or dials a new one if none are idle
+func (p *connPool) get() (net.Conn, error) {
+	p.mu.Lock()
+	if n := len(p.conns); n > 0 {
+		conn := p.conns[n-1]
+		p.conns = p.conns[:n-1]
+		p.mu.Unlock()
+		return conn, nil
+	}
+	p.mu.Unlock()
+
+	return net.DialTimeout("tcp", p.address, dialTimeout)
+}
+
+// put returns a healthy connection to the pool, closing it instead if the
+// pool is already full
+func (p *connPool) put(conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.conns) >= poolSize {
+		conn.Close() //nolint:errcheck
+		return
+	}
+
+	p.conns = append(p.conns, conn)
+}
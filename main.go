@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/natesales/bcg/backend"
+	"github.com/natesales/bcg/config"
+	"github.com/natesales/bcg/daemon"
+	"github.com/natesales/bcg/peering"
+	"github.com/natesales/bcg/peeringdb"
+	"github.com/natesales/bcg/rtr"
+	"github.com/natesales/bcg/verify"
+)
+
+var (
+	release string // This is set by go build
+)
+
+var (
+	configFilename     = flag.String("config", "/etc/bcg/config.yml", "Configuration file in YAML, TOML, or JSON format")
+	outputDirectory    = flag.String("output", "/etc/bird/", "Directory to write output files to")
+	templatesDirectory = flag.String("templates", "/etc/bcg/templates/", "Templates directory")
+	birdSocket         = flag.String("socket", "/run/bird/bird.ctl", "BIRD control socket")
+	printVersion       = flag.Bool("version", false, "Print bcg version and exit")
+	dryRun             = flag.Bool("dryrun", false, "Skip modifying BIRD config. This can be used to test that your config syntax is correct.")
+	statuszAddr        = flag.String("statusz", "", "Address to serve peer verification status on, e.g. :8080 (disabled if empty)")
+	concurrency        = flag.Int("concurrency", 4, "Maximum number of peers to fetch PeeringDB/IRR/RPKI data for at once")
+
+	rtrCache    = flag.String("rtr-cache", "/var/lib/bcg/rtr-cache.json", "Path to the on-disk VRP cache, used if the RTR server is unreachable")
+	embedRoa    = flag.Bool("embed-roa", false, "Render the current VRP snapshot into a roa4/roa6 table in bird.conf, for hosts not running their own RTR cache")
+	validateRoa = flag.Bool("validate-origins", false, "Fail at startup if an originated prefix in Config.Prefixes is not covered by a valid ROA")
+
+	daemonMode     = flag.Bool("daemon", false, "Run as a long-lived daemon with an mTLS API instead of a one-shot generator")
+	daemonAddr     = flag.String("daemon-listen", ":9200", "Address for the daemon API to listen on")
+	daemonStore    = flag.String("daemon-store", "/var/lib/bcg/bcg.db", "Path to the BoltDB file used to persist peers in daemon mode, if -daemon-store-dsn is not set")
+	daemonStoreDSN = flag.String("daemon-store-dsn", "", "Postgres \"postgres://\" DSN to persist peers in, for multi-instance deployments. Overrides -daemon-store")
+	daemonCert     = flag.String("daemon-cert", "/etc/bcg/daemon.crt", "Daemon TLS certificate")
+	daemonKey      = flag.String("daemon-key", "/etc/bcg/daemon.key", "Daemon TLS private key")
+	daemonCA       = flag.String("daemon-ca", "/etc/bcg/clients-ca.crt", "CA bundle used to verify client certificates in daemon mode")
+	daemonAudit    = flag.String("daemon-audit-log", "/var/log/bcg/audit.log", "Path to the daemon's mutation audit log")
+
+	daemonPeeringKey   = flag.String("daemon-peering-key", "/etc/bcg/peering.key", "Signing key used to verify peering tokens this daemon generates")
+	daemonPeeringStore = flag.String("daemon-peering-store", "/var/lib/bcg/peering.db", "Path to the peering session store")
+)
+
+func main() {
+	// "bcg peering <subcommand>" bypasses the top-level flag set entirely,
+	// since each subcommand parses its own flags
+	if len(os.Args) > 1 && os.Args[1] == "peering" {
+		os.Exit(peering.RunCLI(os.Args[2:]))
+	}
+
+	if release == "" {
+		release = "No release set"
+	}
+
+	flag.Usage = func() {
+		fmt.Printf("Usage for bcg (%s) https://github.com/natesales/bcg:\n", release)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *printVersion {
+		fmt.Printf("bcg version (%s) https://github.com/natesales/bcg\n", release)
+		os.Exit(0)
+	}
+
+	log.Info("Starting BCG")
+	log.Info("Generating peer specific files")
+
+	cfg, err := config.Load(*configFilename)
+	if err != nil {
+		log.Fatalf("Load config: %v", err)
+	}
+
+	log.Infof("Loaded config: %+v", cfg)
+
+	// A single RTR client is shared between origin validation and the
+	// optional roa4/roa6 embedding below, so both use one query of the cache
+	// server; peer verification (verify.RPKIProcessor) uses its own client
+	var roaClient *rtr.Client
+	if *validateRoa || *embedRoa {
+		roaClient = rtr.NewClient(cfg.RtrServer)
+		if err := roaClient.LoadCache(*rtrCache); err != nil {
+			log.Infof("No usable RTR cache at %s: %v", *rtrCache, err)
+		}
+
+		if err := roaClient.Refresh(); err != nil {
+			log.Warnf("RTR refresh against %s: %v; falling back to cached VRP set", cfg.RtrServer, err)
+		} else if err := roaClient.SaveCache(*rtrCache); err != nil {
+			log.Warnf("Save RTR cache to %s: %v", *rtrCache, err)
+		}
+
+		if *validateRoa {
+			if err := roaClient.ValidateOriginated(cfg.Asn, cfg.Prefixes); err != nil {
+				log.Fatalf("Validate originated prefixes: %v", err)
+			}
+		}
+	}
+
+	if *daemonMode {
+		runDaemon(cfg)
+		return
+	}
+
+	statusStore := verify.NewStore()
+	if *statuszAddr != "" {
+		http.HandleFunc("/statusz", statusStore.StatuszHandler)
+		go func() {
+			log.Infof("Serving /statusz on %s", *statuszAddr)
+			log.Fatalf("statusz server: %v", http.ListenAndServe(*statuszAddr, nil))
+		}()
+	}
+
+	var roaRoutes4, roaRoutes6 string
+	if *embedRoa && roaClient != nil {
+		if cfg.Backend == "bird" {
+			roaRoutes4, roaRoutes6 = roaClient.RoaRoutes()
+		} else {
+			log.Warnf("-embed-roa is only implemented for the bird backend; ignoring for %s", cfg.Backend)
+		}
+	}
+
+	// Verify chain is shared across peers so its IRR Fetcher's connection
+	// pool and on-disk cache are reused instead of rebuilt per peer
+	chain := verify.DefaultChain()
+
+	// Validate peers, fetching PeeringDB/IRR/RPKI data for at most
+	// -concurrency peers at once so a large peer list doesn't serialize one
+	// round trip per peer
+	sem := make(chan struct{}, *concurrency)
+	var wg sync.WaitGroup
+	for peerName, peerData := range cfg.Peers {
+		peerName, peerData := peerName, peerData
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			peerData.QueryTime = "[No time-specific operations performed]"
+
+			var peeringDbData peeringdb.Data
+			var havePeeringDbData bool
+
+			// If MaxPfx limits should be pulled from PeeringDB
+			if peerData.AutoMaxPfx {
+				if !havePeeringDbData {
+					log.Infof("Running PeeringDB query for AS%d", peerData.Asn)
+					data, err := peeringdb.GetData(peerData.Asn)
+					if err != nil {
+						log.Warnf("PeeringDB query for AS%d: %v; keeping previous max-prefix limits", peerData.Asn, err)
+					} else {
+						peeringDbData = data
+						havePeeringDbData = true
+					}
+				}
+
+				if havePeeringDbData {
+					peerData.MaxPfx4 = int64(peeringDbData.MaxPfx4)
+					peerData.MaxPfx6 = int64(peeringDbData.MaxPfx6)
+					log.Printf("AutoMaxPfx AS%d MaxPfx4: %d", peerData.Asn, peerData.MaxPfx4)
+					log.Printf("AutoMaxPfx AS%d MaxPfx6: %d", peerData.Asn, peerData.MaxPfx6)
+				}
+			}
+
+			// If PfxFilter sets should be pulled from PeeringDB/IRR, and verified
+			// against the IRR and RPKI ROAs before being trusted
+			if peerData.AutoPfxFilter {
+				verifyCtx := &verify.Context{
+					PeerName: peerName,
+					Asn:      peerData.Asn,
+					AsSet:    peerData.AsSet,
+					IrrDb:    cfg.IrrDb,
+					RtrAddr:  cfg.RtrServer,
+					Status:   verify.PeerStatus{PeerName: peerName, Asn: peerData.Asn},
+				}
+
+				if err := chain.Run(verifyCtx); err != nil {
+					// A failed verification degrades to keeping the peer's
+					// previous filter rather than aborting the whole run
+					log.Warnf("Verify AS%d: %v; keeping previous prefix filter", peerData.Asn, err)
+				} else {
+					statusStore.Set(verifyCtx.Status)
+					peerData.PfxFilter4 = verifyCtx.Prefixes4
+					peerData.PfxFilter6 = verifyCtx.Prefixes6
+
+					log.Printf("AutoPfxFilter AS%d Aggregated Entries: %d", peerData.Asn, len(peerData.PfxFilter4))
+					log.Printf("AutoPfxFilter AS%d Aggregated Entries: %d", peerData.Asn, len(peerData.PfxFilter6))
+
+					if !verifyCtx.Status.Passed {
+						log.Warnf("AS%d failed prefix verification; peer.tmpl should gate acceptance using .Status", peerData.Asn)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Validate config: %v", err)
+	}
+
+	log.Infof("Modified config: %+v", cfg)
+
+	// Render the global and peer config through the selected backend,
+	// validate it with the backend's own syntax checker, and only reload
+	// the router if that passes; a failed validation rolls the output
+	// directory back to its previous contents instead of leaving a broken
+	// config in place
+	if !*dryRun {
+		renderer := backend.New(cfg.Backend, *birdSocket)
+		if renderer == nil {
+			log.Fatalf("Unsupported backend %s", cfg.Backend)
+		}
+
+		statuses := map[string]verify.PeerStatus{}
+		for peerName := range cfg.Peers {
+			if status, ok := statusStore.Get(peerName); ok {
+				statuses[peerName] = status
+			}
+		}
+
+		err := backend.ApplyWithRollback(context.Background(), renderer, backend.RenderInput{
+			Config:             *cfg,
+			Statuses:           statuses,
+			TemplatesDirectory: *templatesDirectory,
+			OutputDirectory:    *outputDirectory,
+			RoaRoutes4:         roaRoutes4,
+			RoaRoutes6:         roaRoutes6,
+		})
+		if err != nil {
+			log.Fatalf("Apply %s config: %v", cfg.Backend, err)
+		}
+
+		log.Infof("Applied %s config for %d peers", cfg.Backend, len(cfg.Peers))
+	}
+}
+
+// runDaemon starts bcg in long-running daemon mode, serving the mTLS peer
+// management API instead of generating configs once and exiting. cfg
+// supplies the router-wide settings every peer is rendered against; peers
+// themselves live in the daemon's Store, not the static config file.
+func runDaemon(cfg *config.Config) {
+	var store daemon.Store
+	var err error
+	if *daemonStoreDSN != "" {
+		store, err = daemon.NewPostgresStore(*daemonStoreDSN)
+		if err != nil {
+			log.Fatalf("Open daemon Postgres store: %v", err)
+		}
+	} else {
+		store, err = daemon.NewBoltStore(*daemonStore)
+		if err != nil {
+			log.Fatalf("Open daemon store: %v", err)
+		}
+	}
+
+	server, err := daemon.NewServer(daemon.Options{
+		ListenAddr:         *daemonAddr,
+		TemplatesDirectory: *templatesDirectory,
+		OutputDirectory:    *outputDirectory,
+		BirdSocket:         *birdSocket,
+		ServerCert:         *daemonCert,
+		ServerKey:          *daemonKey,
+		ClientCA:           *daemonCA,
+		AuditLogPath:       *daemonAudit,
+	}, store, *cfg)
+	if err != nil {
+		log.Fatalf("Create daemon server: %v", err)
+	}
+
+	peeringStore, err := peering.NewStore(*daemonPeeringStore)
+	if err != nil {
+		log.Fatalf("Open peering store: %v", err)
+	}
+
+	peeringKey, err := peering.LoadOrCreateSigningKey(*daemonPeeringKey)
+	if err != nil {
+		log.Fatalf("Load peering signing key: %v", err)
+	}
+
+	server.SetHandshake(&peering.Handshake{
+		Store:       peeringStore,
+		SigningKey:  peeringKey,
+		Reconfigure: server.ApplyPeer,
+	})
+
+	replicator := &peering.Replicator{
+		Store:       peeringStore,
+		Reconfigure: server.ApplyPeer,
+		GetPeer:     store.GetPeer,
+		Interval:    time.Minute,
+		CurrentLimits: func(peerName string) (int64, int64) {
+			peer, found, err := store.GetPeer(peerName)
+			if err != nil || !found {
+				return 0, 0
+			}
+			return peer.MaxPfx4, peer.MaxPfx6
+		},
+	}
+	go replicator.Run(nil)
+
+	log.Fatalf("Daemon exited: %v", server.ListenAndServe())
+}
@@ -0,0 +1,223 @@
+package peering
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/natesales/bcg/config"
+)
+
+// RunCLI implements the "bcg peering <subcommand>" family of commands. It
+// returns the process exit code.
+func RunCLI(args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Usage: bcg peering <generate-token|establish|list> [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "generate-token":
+		return runGenerateToken(args[1:])
+	case "establish":
+		return runEstablish(args[1:])
+	case "list":
+		return runList(args[1:])
+	default:
+		fmt.Printf("Unknown peering subcommand %q\n", args[0])
+		return 2
+	}
+}
+
+func runGenerateToken(args []string) int {
+	fs := flag.NewFlagSet("peering generate-token", flag.ExitOnError)
+	configFilename := fs.String("config", "/etc/bcg/config.yml", "Configuration file")
+	keyPath := fs.String("peering-key", "/etc/bcg/peering.key", "Signing key used to verify tokens this instance generates")
+	neighbor4 := fs.String("neighbor4", "", "Comma separated candidate IPv4 neighbor addresses")
+	neighbor6 := fs.String("neighbor6", "", "Comma separated candidate IPv6 neighbor addresses")
+	withSecret := fs.Bool("secret", false, "Generate an MD5/TCP-AO session secret")
+	fs.Parse(args) //nolint:errcheck
+
+	cfg, err := config.Load(*configFilename)
+	if err != nil {
+		log.Fatalf("Load config: %v", err)
+	}
+
+	signingKey, err := LoadOrCreateSigningKey(*keyPath)
+	if err != nil {
+		log.Fatalf("Load peering key: %v", err)
+	}
+
+	token, err := Generate(GenerateOptions{
+		LocalAsn:     cfg.Asn,
+		RouterId:     cfg.RouterId,
+		NeighborIps4: splitNonEmpty(*neighbor4),
+		NeighborIps6: splitNonEmpty(*neighbor6),
+		WithSecret:   *withSecret,
+		SigningKey:   signingKey,
+	})
+	if err != nil {
+		log.Fatalf("Generate token: %v", err)
+	}
+
+	fmt.Println(token)
+	return 0
+}
+
+func runEstablish(args []string) int {
+	fs := flag.NewFlagSet("peering establish", flag.ExitOnError)
+	configFilename := fs.String("config", "/etc/bcg/config.yml", "Configuration file")
+	storePath := fs.String("store", "/var/lib/bcg/peering.db", "Path to the peering session store")
+	token := fs.String("token", "", "Token produced by the remote instance's generate-token")
+	neighbor4 := fs.String("neighbor4", "", "Comma separated local candidate IPv4 neighbor addresses")
+	neighbor6 := fs.String("neighbor6", "", "Comma separated local candidate IPv6 neighbor addresses")
+	maxPfx4 := fs.Int64("maxpfx4", 0, "Proposed IPv4 max-prefix limit")
+	maxPfx6 := fs.Int64("maxpfx6", 0, "Proposed IPv6 max-prefix limit")
+	port := fs.Int("port", 9200, "Port the remote instance's peering API listens on")
+	clientCert := fs.String("client-cert", "/etc/bcg/peering.crt", "Client TLS certificate")
+	clientKey := fs.String("client-key", "/etc/bcg/peering.key.pem", "Client TLS private key")
+	serverCA := fs.String("server-ca", "/etc/bcg/peering-ca.crt", "CA bundle used to verify the remote instance")
+	fs.Parse(args) //nolint:errcheck
+
+	if *token == "" {
+		fmt.Println("--token is required")
+		return 2
+	}
+
+	cfg, err := config.Load(*configFilename)
+	if err != nil {
+		log.Fatalf("Load config: %v", err)
+	}
+
+	store, err := NewStore(*storePath)
+	if err != nil {
+		log.Fatalf("Open peering store: %v", err)
+	}
+	defer store.Close()
+
+	tlsConfig, err := clientTLSConfig(*clientCert, *clientKey, *serverCA)
+	if err != nil {
+		log.Fatalf("Build client TLS config: %v", err)
+	}
+
+	session, err := Establish(EstablishOptions{
+		Token:        *token,
+		RemoteAsn:    cfg.Asn,
+		RouterId:     cfg.RouterId,
+		NeighborIps4: splitNonEmpty(*neighbor4),
+		NeighborIps6: splitNonEmpty(*neighbor6),
+		MaxPfx4:      *maxPfx4,
+		MaxPfx6:      *maxPfx6,
+		TLSConfig:    tlsConfig,
+		Port:         *port,
+	}, store, staticFileReconfigure(*configFilename, cfg))
+	if err != nil {
+		log.Fatalf("Establish: %v", err)
+	}
+
+	fmt.Printf("Established session with AS%d (maxpfx4=%d maxpfx6=%d, families=%v)\n",
+		session.RemoteAsn, session.MaxPfx4, session.MaxPfx6, session.Families)
+	return 0
+}
+
+func runList(args []string) int {
+	fs := flag.NewFlagSet("peering list", flag.ExitOnError)
+	storePath := fs.String("store", "/var/lib/bcg/peering.db", "Path to the peering session store")
+	fs.Parse(args) //nolint:errcheck
+
+	store, err := NewStore(*storePath)
+	if err != nil {
+		log.Fatalf("Open peering store: %v", err)
+	}
+	defer store.Close()
+
+	sessions, err := store.ListSessions()
+	if err != nil {
+		log.Fatalf("List sessions: %v", err)
+	}
+
+	for name, session := range sessions {
+		fmt.Printf("%s\tAS%d\tstate=%s\tmaxpfx4=%d\tmaxpfx6=%d\tfamilies=%v\tnegotiated=%s\n",
+			name, session.RemoteAsn, session.State, session.MaxPfx4, session.MaxPfx6, session.Families, session.NegotiatedAt)
+	}
+
+	return 0
+}
+
+// staticFileReconfigure builds a Reconfigure callback that adds/updates a
+// peer in the static config file. It does not regenerate router config or
+// reload BIRD.
+func staticFileReconfigure(configFilename string, cfg *config.Config) Reconfigure {
+	return func(peerName string, peer *config.Peer) error {
+		if cfg.Peers == nil {
+			cfg.Peers = map[string]*config.Peer{}
+		}
+		cfg.Peers[peerName] = peer
+		return config.Save(configFilename, cfg)
+	}
+}
+
+// LoadOrCreateSigningKey reads the signing key at path, generating and
+// persisting a new one if it doesn't exist yet
+func LoadOrCreateSigningKey(path string) ([]byte, error) {
+	key, err := ioutil.ReadFile(path)
+	if err == nil {
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	generated, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, []byte(generated), 0600); err != nil {
+		return nil, err
+	}
+
+	log.Infof("Generated new peering signing key at %s", path)
+	return []byte(generated), nil
+}
+
+func clientTLSConfig(certPath, keyPath, caPath string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("load client certificate: %v", err)
+	}
+
+	caBytes, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("read server CA bundle: %v", err)
+	}
+
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in %s", caPath)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caPool,
+	}, nil
+}
+
+func splitNonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
@@ -0,0 +1,290 @@
+package peering
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/natesales/bcg/config"
+)
+
+// negotiateRequest is what the redeeming side (operator B) posts to the
+// token-generating side (operator A) to establish a session
+type negotiateRequest struct {
+	Token        string   `json:"token"`
+	RemoteAsn    uint32   `json:"remote_asn"` // B's ASN
+	RouterId     string   `json:"router_id"`  // B's router ID
+	NeighborIps4 []string `json:"neighbor_ips4"`
+	NeighborIps6 []string `json:"neighbor_ips6"`
+	MaxPfx4      int64    `json:"max_pfx4"`
+	MaxPfx6      int64    `json:"max_pfx6"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// negotiateResponse is A's reply once it has accepted the session and
+// written B's Peer entry into its own config
+type negotiateResponse struct {
+	Accepted     bool     `json:"accepted"`
+	Reason       string   `json:"reason,omitempty"`
+	LocalAsn     uint32   `json:"local_asn"` // A's ASN
+	RouterId     string   `json:"router_id"` // A's router ID
+	NeighborIps4 []string `json:"neighbor_ips4"`
+	NeighborIps6 []string `json:"neighbor_ips6"`
+	MaxPfx4      int64    `json:"max_pfx4"`
+	MaxPfx6      int64    `json:"max_pfx6"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// Reconfigure is called with a newly negotiated peer so the caller can
+// write it wherever peers live (a static config file or a daemon Store)
+// and trigger a BIRD reconfigure. Kept as a callback so this package
+// doesn't need to depend on the daemon or one-shot generator.
+type Reconfigure func(peerName string, peer *config.Peer) error
+
+// Handshake handles incoming establish requests for tokens generated by
+// this instance. The exchange runs over the daemon's existing mTLS HTTP
+// API rather than a dedicated gRPC stream, reusing the same client
+// certificate trust established for peer CRUD.
+type Handshake struct {
+	Store       *Store
+	SigningKey  []byte
+	Reconfigure Reconfigure
+}
+
+// HandleEstablish is the HTTP handler operator A runs to accept sessions
+// redeemed against tokens it generated
+func (h *Handshake) HandleEstablish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req negotiateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := Parse(req.Token, h.SigningKey)
+	if err != nil {
+		writeRejection(w, fmt.Sprintf("invalid token: %v", err))
+		return
+	}
+
+	claimed, err := h.Store.ClaimNonce(token.Nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !claimed {
+		writeRejection(w, "token has already been redeemed")
+		return
+	}
+
+	maxPfx4 := negotiateMaxPfx(req.MaxPfx4, defaultMaxPfx4)
+	maxPfx6 := negotiateMaxPfx(req.MaxPfx6, defaultMaxPfx6)
+	families := negotiateFamilies(token.NeighborIps4, token.NeighborIps6, req.NeighborIps4, req.NeighborIps6)
+
+	peerName := fmt.Sprintf("AS%d", req.RemoteAsn)
+	peer := &config.Peer{
+		Asn:           req.RemoteAsn,
+		NeighborIps:   append(append([]string{}, req.NeighborIps4...), req.NeighborIps6...),
+		Secret:        token.Secret,
+		ImportPolicy:  "cone",
+		ExportPolicy:  "any",
+		AutoPfxFilter: true, // no AS-Set to hand-enter for a peer negotiated over the wire; pull its filter from the IRRDB instead
+		MaxPfx4:       maxPfx4,
+		MaxPfx6:       maxPfx6,
+	}
+
+	if err := h.Reconfigure(peerName, peer); err != nil {
+		writeRejection(w, fmt.Sprintf("reconfigure: %v", err))
+		return
+	}
+
+	session := Session{
+		PeerName:     peerName,
+		LocalAsn:     token.LocalAsn,
+		RemoteAsn:    req.RemoteAsn,
+		Families:     families,
+		MaxPfx4:      maxPfx4,
+		MaxPfx6:      maxPfx6,
+		Capabilities: req.Capabilities,
+		State:        "established",
+		NegotiatedAt: time.Now(),
+	}
+	if err := h.Store.SaveSession(session); err != nil {
+		log.Warnf("Save peering session %s: %v", peerName, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(negotiateResponse{ //nolint:errcheck
+		Accepted:     true,
+		LocalAsn:     token.LocalAsn,
+		RouterId:     token.RouterId,
+		NeighborIps4: token.NeighborIps4,
+		NeighborIps6: token.NeighborIps6,
+		MaxPfx4:      maxPfx4,
+		MaxPfx6:      maxPfx6,
+		Capabilities: req.Capabilities,
+	})
+}
+
+func writeRejection(w http.ResponseWriter, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(negotiateResponse{Accepted: false, Reason: reason}) //nolint:errcheck
+}
+
+// EstablishOptions carries operator B's side of the handshake
+type EstablishOptions struct {
+	Token        string
+	RemoteAsn    uint32
+	RouterId     string
+	NeighborIps4 []string
+	NeighborIps6 []string
+	MaxPfx4      int64
+	MaxPfx6      int64
+	Capabilities []string
+	TLSConfig    *tls.Config // mTLS client config used to dial the generating instance
+	Port         int
+}
+
+// Establish redeems token against the instance that generated it, writes
+// the resulting Peer locally via reconfigure, and returns the negotiated Session
+func Establish(opts EstablishOptions, store *Store, reconfigure Reconfigure) (*Session, error) {
+	// The token embeds operator A's candidate addresses; we don't need (or
+	// have) A's signing key to read them back out, only to verify integrity,
+	// which is A's job when we post the token back to it.
+	unverified, err := unsafeTokenFields(opts.Token)
+	if err != nil {
+		return nil, fmt.Errorf("read token: %v", err)
+	}
+
+	req := negotiateRequest{
+		Token:        opts.Token,
+		RemoteAsn:    opts.RemoteAsn,
+		RouterId:     opts.RouterId,
+		NeighborIps4: opts.NeighborIps4,
+		NeighborIps6: opts.NeighborIps6,
+		MaxPfx4:      opts.MaxPfx4,
+		MaxPfx6:      opts.MaxPfx6,
+		Capabilities: opts.Capabilities,
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal establish request: %v", err)
+	}
+
+	resp, err := dialCandidates(unverified, opts.Port, opts.TLSConfig, body)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Accepted {
+		return nil, fmt.Errorf("AS%d rejected the peering token: %s", unverified.LocalAsn, resp.Reason)
+	}
+
+	peerName := fmt.Sprintf("AS%d", unverified.LocalAsn)
+	peer := &config.Peer{
+		Asn:           unverified.LocalAsn,
+		NeighborIps:   append(append([]string{}, resp.NeighborIps4...), resp.NeighborIps6...),
+		Secret:        unverified.Secret,
+		ImportPolicy:  "cone",
+		ExportPolicy:  "any",
+		AutoPfxFilter: true, // no AS-Set to hand-enter for a peer negotiated over the wire; pull its filter from the IRRDB instead
+		MaxPfx4:       resp.MaxPfx4,
+		MaxPfx6:       resp.MaxPfx6,
+	}
+
+	if err := reconfigure(peerName, peer); err != nil {
+		return nil, fmt.Errorf("reconfigure %s: %v", peerName, err)
+	}
+
+	session := Session{
+		PeerName:     peerName,
+		LocalAsn:     opts.RemoteAsn,
+		RemoteAsn:    unverified.LocalAsn,
+		Families:     negotiateFamilies(opts.NeighborIps4, opts.NeighborIps6, resp.NeighborIps4, resp.NeighborIps6),
+		MaxPfx4:      resp.MaxPfx4,
+		MaxPfx6:      resp.MaxPfx6,
+		Capabilities: resp.Capabilities,
+		State:        "established",
+		NegotiatedAt: time.Now(),
+	}
+	if err := store.SaveSession(session); err != nil {
+		return nil, fmt.Errorf("save session %s: %v", peerName, err)
+	}
+
+	return &session, nil
+}
+
+// dialCandidates tries each of A's candidate addresses in turn until one accepts the POST
+func dialCandidates(token Token, port int, tlsConfig *tls.Config, body []byte) (*negotiateResponse, error) {
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	candidates := append(append([]string{}, token.NeighborIps4...), token.NeighborIps6...)
+	var lastErr error
+	for _, addr := range candidates {
+		url := fmt.Sprintf("https://%s:%d/v1/peering/establish", addr, port)
+		res, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		defer res.Body.Close()
+
+		var resp negotiateResponse
+		if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+			lastErr = err
+			continue
+		}
+		return &resp, nil
+	}
+
+	return nil, fmt.Errorf("no candidate address accepted the establish request, last error: %v", lastErr)
+}
+
+// unsafeTokenFields decodes a token's fields without verifying its
+// signature, for a redeemer that doesn't hold the generating side's key
+func unsafeTokenFields(encoded string) (Token, error) {
+	raw, err := base64Decode(encoded)
+	if err != nil {
+		return Token{}, err
+	}
+
+	var token Token
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return Token{}, fmt.Errorf("unmarshal token: %v", err)
+	}
+	return token, nil
+}
+
+// negotiateMaxPfx caps a peer-proposed max-prefix limit at def, the
+// instance's own ceiling for a newly auto-established session
+func negotiateMaxPfx(proposed, def int64) int64 {
+	if proposed <= 0 || proposed > def {
+		return def
+	}
+	return proposed
+}
+
+// negotiateFamilies reports which address families both sides offered neighbor IPs for
+func negotiateFamilies(localV4, localV6, remoteV4, remoteV6 []string) []string {
+	var families []string
+	if len(localV4) > 0 && len(remoteV4) > 0 {
+		families = append(families, "ipv4")
+	}
+	if len(localV6) > 0 && len(remoteV6) > 0 {
+		families = append(families, "ipv6")
+	}
+	return families
+}
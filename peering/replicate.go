@@ -0,0 +1,85 @@
+package peering
+
+import (
+	"crypto/tls"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/natesales/bcg/config"
+)
+
+// Replicator periodically compares each stored session's max-prefix limits
+// with this instance's current limits for that peer and, where they differ,
+// re-applies the peer locally through Reconfigure and records the new limits
+// on the session. It sends nothing to the remote instance; TLSConfig and
+// Port are not used.
+type Replicator struct {
+	Store       *Store
+	Reconfigure Reconfigure
+	TLSConfig   *tls.Config
+	Port        int
+	Interval    time.Duration
+	// CurrentLimits returns this instance's live max-prefix limits for a
+	// peer, so the replicator always sends up-to-date values
+	CurrentLimits func(peerName string) (maxPfx4, maxPfx6 int64)
+	// GetPeer returns the full persisted config for a peer, so replication
+	// can update MaxPfx4/MaxPfx6 without clobbering every other field
+	GetPeer func(peerName string) (*config.Peer, bool, error)
+}
+
+// Run loops forever, replicating session state on Interval until stop is closed
+func (r *Replicator) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.replicateAll()
+		}
+	}
+}
+
+func (r *Replicator) replicateAll() {
+	sessions, err := r.Store.ListSessions()
+	if err != nil {
+		log.Warnf("Replicate: list sessions: %v", err)
+		return
+	}
+
+	for name, session := range sessions {
+		maxPfx4, maxPfx6 := r.CurrentLimits(name)
+		if maxPfx4 == session.MaxPfx4 && maxPfx6 == session.MaxPfx6 {
+			continue
+		}
+
+		log.Infof("Replicating updated limits for %s: maxpfx4=%d maxpfx6=%d", name, maxPfx4, maxPfx6)
+
+		peer, found, err := r.GetPeer(name)
+		if err != nil {
+			log.Warnf("Replicate %s: get peer: %v", name, err)
+			continue
+		}
+		if !found {
+			log.Warnf("Replicate %s: no persisted peer, skipping", name)
+			continue
+		}
+
+		peer.MaxPfx4 = maxPfx4
+		peer.MaxPfx6 = maxPfx6
+		if err := r.Reconfigure(name, peer); err != nil {
+			log.Warnf("Replicate %s: reconfigure: %v", name, err)
+			continue
+		}
+
+		session.MaxPfx4 = maxPfx4
+		session.MaxPfx6 = maxPfx6
+		session.LastReplicate = time.Now()
+		if err := r.Store.SaveSession(session); err != nil {
+			log.Warnf("Replicate %s: save session: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package peering
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	noncesBucket   = []byte("claimed_nonces")
+	sessionsBucket = []byte("sessions")
+)
+
+// Session is the negotiated state of a peering established (or being
+// established) with a remote bcg instance
+type Session struct {
+	PeerName      string    `json:"peer_name"`
+	LocalAsn      uint32    `json:"local_asn"`
+	RemoteAsn     uint32    `json:"remote_asn"`
+	Families      []string  `json:"families"` // e.g. ["ipv4", "ipv6"]
+	MaxPfx4       int64     `json:"max_pfx4"`
+	MaxPfx6       int64     `json:"max_pfx6"`
+	Capabilities  []string  `json:"capabilities"`
+	State         string    `json:"state"` // "pending", "established", "failed"
+	NegotiatedAt  time.Time `json:"negotiated_at"`
+	LastReplicate time.Time `json:"last_replicate"`
+}
+
+// Store persists claimed token nonces (so a token can only ever be redeemed
+// once) and the negotiated state of every session, in the same BoltDB file
+type Store struct {
+	db *bolt.DB
+}
+
+// NewStore opens (creating if necessary) a peering Store at path
+func NewStore(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open peering store at %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(noncesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create peering buckets: %v", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// ClaimNonce marks a token's nonce as used, returning false if it was
+// already claimed (making handshake redemption idempotent: a retried
+// establish with the same token fails instead of double-applying)
+func (s *Store) ClaimNonce(nonce string) (bool, error) {
+	claimed := false
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(noncesBucket)
+		if bucket.Get([]byte(nonce)) != nil {
+			claimed = true
+			return nil
+		}
+		return bucket.Put([]byte(nonce), []byte(time.Now().Format(time.RFC3339)))
+	})
+
+	return !claimed, err
+}
+
+// SaveSession persists the negotiated state of a session
+func (s *Store) SaveSession(session Session) error {
+	encoded, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("marshal session %s: %v", session.PeerName, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put([]byte(session.PeerName), encoded)
+	})
+}
+
+// ListSessions returns every negotiated session, keyed by peer name
+func (s *Store) ListSessions() (map[string]Session, error) {
+	sessions := map[string]Session{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+			var session Session
+			if err := json.Unmarshal(v, &session); err != nil {
+				return fmt.Errorf("unmarshal session %s: %v", k, err)
+			}
+			sessions[string(k)] = session
+			return nil
+		})
+	})
+
+	return sessions, err
+}
+
+// Close releases the underlying BoltDB handle
+func (s *Store) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,136 @@
+// Package peering lets two bcg-managed routers bootstrap a BGP session
+// without either operator hand-editing the other's config: one side
+// generates a single-use token, the other redeems it over a mutually
+// authenticated handshake.
+package peering
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// tokenTTL bounds how long a generated token can be redeemed for
+const tokenTTL = 15 * time.Minute
+
+// Default max-prefix ceilings applied when negotiating a session, mirroring
+// the upstream-session defaults the one-shot generator uses for ImportPolicy "any"
+const (
+	defaultMaxPfx4 = 1000000
+	defaultMaxPfx6 = 100000
+)
+
+// base64Decode decodes the URL-safe base64 encoding tokens are transported in
+func base64Decode(encoded string) ([]byte, error) {
+	return base64.URLEncoding.DecodeString(encoded)
+}
+
+// Token is the opaque, signed payload handed from the generating operator
+// to the one establishing the session. It is single-use: redeeming it
+// consumes its Nonce (see Store.ClaimNonce).
+type Token struct {
+	LocalAsn     uint32   `json:"local_asn"`
+	RouterId     string   `json:"router_id"`
+	NeighborIps4 []string `json:"neighbor_ips4"`
+	NeighborIps6 []string `json:"neighbor_ips6"`
+	Secret       string   `json:"secret,omitempty"` // base64 MD5/TCP-AO key, empty if unauthenticated
+	Nonce        string   `json:"nonce"`
+	ExpiresAt    int64    `json:"expires_at"` // Unix seconds
+	Signature    string   `json:"signature"`  // base64 HMAC-SHA256 over the fields above
+}
+
+// GenerateOptions carries the local information needed to mint a token
+type GenerateOptions struct {
+	LocalAsn     uint32
+	RouterId     string
+	NeighborIps4 []string
+	NeighborIps6 []string
+	WithSecret   bool   // generate an MD5/TCP-AO secret for the session
+	SigningKey   []byte // shared secret used to sign (and later verify) the token
+}
+
+// Generate mints a signed, single-use token for establishing a session. The
+// token does not name the remote ASN, so it can be redeemed from any ASN.
+func Generate(opts GenerateOptions) (string, error) {
+	nonce, err := randomString(16)
+	if err != nil {
+		return "", fmt.Errorf("generate nonce: %v", err)
+	}
+
+	var secret string
+	if opts.WithSecret {
+		secret, err = randomString(24)
+		if err != nil {
+			return "", fmt.Errorf("generate session secret: %v", err)
+		}
+	}
+
+	token := Token{
+		LocalAsn:     opts.LocalAsn,
+		RouterId:     opts.RouterId,
+		NeighborIps4: opts.NeighborIps4,
+		NeighborIps6: opts.NeighborIps6,
+		Secret:       secret,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(tokenTTL).Unix(),
+	}
+
+	token.Signature = sign(token, opts.SigningKey)
+
+	encoded, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("marshal token: %v", err)
+	}
+
+	return base64.URLEncoding.EncodeToString(encoded), nil
+}
+
+// Parse decodes and verifies a token against signingKey, rejecting it if
+// it's malformed, unsigned correctly, or expired
+func Parse(encoded string, signingKey []byte) (*Token, error) {
+	raw, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode token: %v", err)
+	}
+
+	var token Token
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return nil, fmt.Errorf("unmarshal token: %v", err)
+	}
+
+	signature := token.Signature
+	token.Signature = ""
+	if !hmac.Equal([]byte(sign(token, signingKey)), []byte(signature)) {
+		return nil, fmt.Errorf("token signature is invalid")
+	}
+	token.Signature = signature
+
+	if time.Now().Unix() > token.ExpiresAt {
+		return nil, fmt.Errorf("token expired at %s", time.Unix(token.ExpiresAt, 0))
+	}
+
+	return &token, nil
+}
+
+// sign computes the HMAC-SHA256 of a token's fields (with Signature cleared) over signingKey
+func sign(token Token, signingKey []byte) string {
+	token.Signature = ""
+	payload, _ := json.Marshal(token) //nolint:errcheck // fields are all directly serializable
+
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// randomString returns a URL-safe random token of n raw bytes
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,124 @@
+package peering
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func testGenerateOptions(signingKey []byte) GenerateOptions {
+	return GenerateOptions{
+		LocalAsn:     65000,
+		RouterId:     "192.0.2.1",
+		NeighborIps4: []string{"192.0.2.1"},
+		NeighborIps6: []string{"2001:db8::1"},
+		SigningKey:   signingKey,
+	}
+}
+
+func TestGenerateAndParseRoundTrip(t *testing.T) {
+	signingKey := []byte("test-signing-key")
+
+	encoded, err := Generate(testGenerateOptions(signingKey))
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	token, err := Parse(encoded, signingKey)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if token.LocalAsn != 65000 || token.RouterId != "192.0.2.1" {
+		t.Fatalf("unexpected token fields: %+v", token)
+	}
+	if token.Secret != "" {
+		t.Fatalf("expected no secret when WithSecret is false, got %q", token.Secret)
+	}
+}
+
+func TestGenerateWithSecret(t *testing.T) {
+	signingKey := []byte("test-signing-key")
+
+	opts := testGenerateOptions(signingKey)
+	opts.WithSecret = true
+
+	encoded, err := Generate(opts)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	token, err := Parse(encoded, signingKey)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if token.Secret == "" {
+		t.Fatalf("expected a generated secret")
+	}
+}
+
+func TestParseRejectsWrongSigningKey(t *testing.T) {
+	encoded, err := Generate(testGenerateOptions([]byte("correct-key")))
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if _, err := Parse(encoded, []byte("wrong-key")); err == nil {
+		t.Fatalf("expected Parse to reject a token signed with a different key")
+	}
+}
+
+func TestParseRejectsTamperedFields(t *testing.T) {
+	signingKey := []byte("test-signing-key")
+	opts := testGenerateOptions(signingKey)
+	opts.LocalAsn = 65000
+
+	encoded, err := Generate(opts)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	raw, err := base64Decode(encoded)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// Flip the ASN digit embedded in the JSON payload without re-signing
+	tampered := raw
+	for i, b := range tampered {
+		if b == '5' {
+			tampered[i] = '6'
+			break
+		}
+	}
+	reencoded := base64.URLEncoding.EncodeToString(tampered)
+
+	if _, err := Parse(reencoded, signingKey); err == nil {
+		t.Fatalf("expected Parse to reject a tampered token")
+	}
+}
+
+func TestParseRejectsExpiredToken(t *testing.T) {
+	signingKey := []byte("test-signing-key")
+	opts := testGenerateOptions(signingKey)
+
+	token := Token{
+		LocalAsn:     opts.LocalAsn,
+		RouterId:     opts.RouterId,
+		NeighborIps4: opts.NeighborIps4,
+		NeighborIps6: opts.NeighborIps6,
+		Nonce:        "fixed-nonce",
+		ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+	}
+	token.Signature = sign(token, signingKey)
+
+	raw, err := json.Marshal(token)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	encoded := base64.URLEncoding.EncodeToString(raw)
+
+	if _, err := Parse(encoded, signingKey); err == nil {
+		t.Fatalf("expected Parse to reject an expired token")
+	}
+}
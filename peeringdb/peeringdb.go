@@ -0,0 +1,59 @@
+// Package peeringdb queries peeringdb.com for peer network metadata
+package peeringdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Response contains the response from a PeeringDB query
+type Response struct {
+	Data []Data `json:"data"`
+}
+
+// Data contains the actual data from a PeeringDB response
+type Data struct {
+	Name    string `json:"name"`
+	AsSet   string `json:"irr_as_set"`
+	MaxPfx4 uint32 `json:"info_prefixes4"`
+	MaxPfx6 uint32 `json:"info_prefixes6"`
+}
+
+// GetData queries PeeringDB for an ASN and returns its network data
+func GetData(asn uint32) (Data, error) {
+	httpClient := http.Client{Timeout: time.Second * 5}
+	req, err := http.NewRequest(http.MethodGet, "https://peeringdb.com/api/net?asn="+strconv.Itoa(int(asn)), nil)
+	if err != nil {
+		return Data{}, fmt.Errorf("PeeringDB GET (This peer might not have a PeeringDB page): %v", err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return Data{}, fmt.Errorf("PeeringDB GET request: %v", err)
+	}
+
+	if res.Body != nil {
+		//noinspection GoUnhandledErrorResult
+		defer res.Body.Close()
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return Data{}, fmt.Errorf("PeeringDB read: %v", err)
+	}
+
+	var peeringDbResponse Response
+	if err := json.Unmarshal(body, &peeringDbResponse); err != nil {
+		return Data{}, fmt.Errorf("PeeringDB JSON unmarshal: %v", err)
+	}
+
+	if len(peeringDbResponse.Data) == 0 {
+		return Data{}, fmt.Errorf("AS%d has no PeeringDB page", asn)
+	}
+
+	return peeringDbResponse.Data[0], nil
+}
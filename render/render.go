@@ -0,0 +1,113 @@
+// Package render executes the peer and global router config templates
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/kennygrant/sanitize"
+
+	"github.com/natesales/bcg/config"
+	"github.com/natesales/bcg/verify"
+)
+
+// PeerTemplate contains a peer-specific config sent to template
+type PeerTemplate struct {
+	Peer             config.Peer
+	Name             string
+	PfxFilterString4 string // Contains string representation of IPv4 prefix filter
+	PfxFilterString6 string // Contains string representation of IPv6 prefix filter
+	Global           config.Config
+	Status           verify.PeerStatus // Verification result, used to gate acceptance
+}
+
+// GlobalTemplate contains the global config sent to template
+type GlobalTemplate struct {
+	Config        config.Config
+	OriginString4 string
+	OriginString6 string
+	OriginList4   []string
+	OriginList6   []string
+	RoaRoutes4    string // BIRD static route statements for the roa4 table, empty unless embedding a VRP snapshot
+	RoaRoutes6    string // BIRD static route statements for the roa6 table, empty unless embedding a VRP snapshot
+}
+
+// funcMap contains the functions made available inside templates
+var funcMap = template.FuncMap{
+	"Contains": func(s, substr string) bool { return strings.Contains(s, substr) },
+	"Iterate": func(count *uint) []uint {
+		var i uint
+		var items []uint
+		for i = 0; i < (*count); i++ {
+			items = append(items, i)
+		}
+		return items
+	},
+}
+
+// Normalize normalizes a string to be filename-safe
+func Normalize(input string) string {
+	// Remove non-alphanumeric characters
+	input = sanitize.Path(input)
+
+	// Make uppercase
+	input = strings.ToUpper(input)
+
+	// Replace spaces with underscores
+	input = strings.ReplaceAll(input, " ", "_")
+
+	// Replace slashes with dashes
+	input = strings.ReplaceAll(input, "/", "-")
+
+	return input
+}
+
+// PeerBytes loads the peer template and renders it to memory, without
+// touching disk. Callers that need to diff against the existing file (e.g.
+// the daemon) render to bytes first and only write if the result changed.
+func PeerBytes(templatesDirectory string, data *PeerTemplate) ([]byte, error) {
+	peerTemplate, err := template.New("").Funcs(funcMap).ParseFiles(path.Join(templatesDirectory, "peer.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := peerTemplate.ExecuteTemplate(&buf, "peer.tmpl", data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Peer loads the peer template and renders it for a single peer
+func Peer(templatesDirectory string, outputDirectory string, fileName string, data *PeerTemplate) error {
+	rendered, err := PeerBytes(templatesDirectory, data)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(outputDirectory, fileName), rendered, 0644)
+}
+
+// Global loads the global template and renders it to fileName under
+// outputDirectory. fileName is caller-supplied (rather than hardcoded to
+// bird.conf) so non-BIRD backends can render to their own config file name.
+func Global(templatesDirectory string, outputDirectory string, fileName string, data *GlobalTemplate) error {
+	globalTemplate, err := template.New("").Funcs(funcMap).ParseFiles(path.Join(templatesDirectory, "global.tmpl"))
+	if err != nil {
+		return err
+	}
+
+	globalFile, err := os.Create(path.Join(outputDirectory, fileName))
+	if err != nil {
+		return err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer globalFile.Close()
+
+	return globalTemplate.ExecuteTemplate(globalFile, "global.tmpl", data)
+}
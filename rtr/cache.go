@@ -0,0 +1,44 @@
+package rtr
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// diskCache is the on-disk representation of a Client's last-known VRP set,
+// so bcg can still generate a working config when the RTR server is unreachable
+type diskCache struct {
+	Vrps      []VRP  `json:"vrps"`
+	SessionID uint16 `json:"session_id"`
+	Serial    uint32 `json:"serial"`
+}
+
+// SaveCache persists the client's current VRP set to path
+func (c *Client) SaveCache(path string) error {
+	encoded, err := json.Marshal(diskCache{Vrps: c.Vrps, SessionID: c.SessionID, Serial: c.Serial})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, encoded, 0644)
+}
+
+// LoadCache seeds the client's VRP set from a previously saved cache at
+// path, for use when the RTR server can't be reached at startup. The
+// loaded set is used as-is, without attempting an incremental Serial Query
+// against it, since the cache may be stale.
+func (c *Client) LoadCache(path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var cached diskCache
+	if err := json.Unmarshal(raw, &cached); err != nil {
+		return err
+	}
+
+	c.Vrps = cached.Vrps
+	c.SessionID = cached.SessionID
+	c.Serial = cached.Serial
+	return nil
+}
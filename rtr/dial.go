@@ -0,0 +1,15 @@
+package rtr
+
+import (
+	"net"
+	"time"
+)
+
+func dial(address string) (net.Conn, error) {
+	return net.DialTimeout("tcp", address, 10*time.Second)
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
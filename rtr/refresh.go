@@ -0,0 +1,87 @@
+package rtr
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// notifyPollInterval is how often a dedicated connection is opened just to
+// check for a pending Serial Notify, between the regular refresh Interval
+const notifyPollInterval = 30 * time.Second
+
+// Watch refreshes the client on a timer, and more promptly whenever the
+// cache server announces a new serial via Serial Notify, until stop is
+// closed. onUpdate is called after a successful initial refresh, and after
+// every later successful refresh that changed the VRP set or its serial.
+func (c *Client) Watch(interval time.Duration, stop <-chan struct{}, onUpdate func()) {
+	if err := c.Refresh(); err != nil {
+		log.Warnf("Initial RTR refresh against %s: %v", c.Address, err)
+	} else if onUpdate != nil {
+		onUpdate()
+	}
+
+	refreshTicker := time.NewTicker(interval)
+	notifyTicker := time.NewTicker(notifyPollInterval)
+	defer refreshTicker.Stop()
+	defer notifyTicker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+
+		case <-refreshTicker.C:
+			c.refreshAndNotify(onUpdate)
+
+		case <-notifyTicker.C:
+			notified, err := c.checkSerialNotify()
+			if err != nil {
+				log.Warnf("RTR serial notify check against %s: %v", c.Address, err)
+				continue
+			}
+			if notified {
+				c.refreshAndNotify(onUpdate)
+			}
+		}
+	}
+}
+
+func (c *Client) refreshAndNotify(onUpdate func()) {
+	before, beforeSerial := len(c.Vrps), c.Serial
+	if err := c.Refresh(); err != nil {
+		log.Warnf("RTR refresh against %s: %v", c.Address, err)
+		return
+	}
+	if onUpdate != nil && (len(c.Vrps) != before || c.Serial != beforeSerial) {
+		onUpdate()
+	}
+}
+
+// checkSerialNotify briefly connects and waits to see whether the server
+// proactively sends a Serial Notify PDU, without performing a full query
+func (c *Client) checkSerialNotify() (bool, error) {
+	if !c.synced {
+		return false, nil
+	}
+
+	conn, err := dial(c.Address)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		return false, err
+	}
+
+	header := make([]byte, 8)
+	if _, err := readFull(conn, header); err != nil {
+		if isTimeout(err) {
+			return false, nil // no notify pending, nothing to do yet
+		}
+		return false, err
+	}
+
+	return header[1] == pduSerialNotify, nil
+}
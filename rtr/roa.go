@@ -0,0 +1,26 @@
+package rtr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoaRoutes renders the client's current VRP set as BIRD static roa4/roa6
+// route statements, for hosts that can't run gortr/stayrtr locally and
+// instead want the ROA table baked directly into bird.conf at generation
+// time. The caller is expected to wrap the returned strings in a
+// "protocol static { roa4 { table ...; }; ... }" block in the template.
+func (c *Client) RoaRoutes() (routes4 string, routes6 string) {
+	var lines4, lines6 []string
+
+	for _, vrp := range c.Vrps {
+		line := fmt.Sprintf("\troute %s max %d as %d;", vrp.Prefix, vrp.MaxLength, vrp.Asn)
+		if strings.Contains(vrp.Prefix, ":") {
+			lines6 = append(lines6, line)
+		} else {
+			lines4 = append(lines4, line)
+		}
+	}
+
+	return strings.Join(lines4, "\n"), strings.Join(lines6, "\n")
+}
@@ -0,0 +1,277 @@
+// Package rtr implements an RPKI-to-Router (RFC 8210) client used to
+// validate route origins against the ROAs held by an RTR cache server, and
+// to export that VRP set into a BIRD roa4/roa6 table.
+package rtr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// PDU types used by this client, per RFC 8210 section 5
+const (
+	pduSerialNotify = 0
+	pduSerialQuery  = 1
+	pduResetQuery   = 2
+	pduCacheResp    = 3
+	pduIPv4Prefix   = 4
+	pduIPv6Prefix   = 6
+	pduEndOfData    = 7
+	pduCacheReset   = 8
+	pduErrorReport  = 10
+)
+
+// VRP is a Validated ROA Payload: an origin ASN authorized to originate a prefix up to maxLength
+type VRP struct {
+	Prefix    string `json:"prefix"`
+	MaxLength uint8  `json:"max_length"`
+	Asn       uint32 `json:"asn"`
+}
+
+func (v VRP) key() string { return fmt.Sprintf("%s-%d-%d", v.Prefix, v.MaxLength, v.Asn) }
+
+// Client maintains a connection to an RTR cache server and the VRP set it
+// has learned, refreshing it incrementally via Serial Query once an initial
+// Reset Query has established a session
+type Client struct {
+	Address string
+
+	Vrps      []VRP
+	SessionID uint16
+	Serial    uint32
+	synced    bool
+}
+
+// NewClient creates an RTR client for the given cache server address ("host:port")
+func NewClient(address string) *Client {
+	return &Client{Address: address}
+}
+
+// Refresh brings the client's VRP set up to date: a Reset Query the first
+// time it's called, a Serial Query (falling back to Reset Query if the
+// server reports our serial is too old) on every call after that
+func (c *Client) Refresh() error {
+	conn, err := dial(c.Address)
+	if err != nil {
+		return fmt.Errorf("RTR connect to %s: %v", c.Address, err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer conn.Close()
+
+	if !c.synced {
+		return c.resetQuery(conn)
+	}
+
+	reset, err := c.serialQuery(conn)
+	if err != nil {
+		return err
+	}
+	if reset {
+		// The cache no longer holds our serial number; start over
+		conn2, err := dial(c.Address)
+		if err != nil {
+			return fmt.Errorf("RTR reconnect to %s: %v", c.Address, err)
+		}
+		defer conn2.Close()
+		return c.resetQuery(conn2)
+	}
+
+	return nil
+}
+
+// resetQuery performs a full Reset Query, replacing the in-memory VRP set
+func (c *Client) resetQuery(conn net.Conn) error {
+	if _, err := conn.Write([]byte{1, pduResetQuery, 0, 0, 0, 0, 0, 8}); err != nil {
+		return fmt.Errorf("RTR send reset query: %v", err)
+	}
+
+	vrps, sessionID, serial, err := c.readPduStream(conn, nil)
+	if err != nil {
+		return err
+	}
+
+	c.Vrps = vrps
+	c.SessionID = sessionID
+	c.Serial = serial
+	c.synced = true
+	return nil
+}
+
+// serialQuery performs an incremental Serial Query, applying announce/withdraw
+// Prefix PDUs to the existing VRP set. It reports reset=true if the server
+// responded with a Cache Reset, meaning a full Reset Query is required instead.
+func (c *Client) serialQuery(conn net.Conn) (reset bool, err error) {
+	query := make([]byte, 12)
+	query[0] = 1
+	query[1] = pduSerialQuery
+	binary.BigEndian.PutUint16(query[2:4], c.SessionID)
+	binary.BigEndian.PutUint32(query[4:8], 12)
+	binary.BigEndian.PutUint32(query[8:12], c.Serial)
+
+	if _, err := conn.Write(query); err != nil {
+		return false, fmt.Errorf("RTR send serial query: %v", err)
+	}
+
+	vrps, sessionID, serial, err := c.readPduStream(conn, c.Vrps)
+	if err != nil {
+		if err == errCacheReset {
+			return true, nil
+		}
+		return false, err
+	}
+
+	c.Vrps = vrps
+	c.SessionID = sessionID
+	c.Serial = serial
+	return false, nil
+}
+
+// errCacheReset signals that the server responded to a Serial Query with a
+// Cache Reset PDU instead of incremental data
+var errCacheReset = fmt.Errorf("RTR server sent Cache Reset")
+
+// readPduStream reads PDUs until End of Data, applying Prefix PDUs on top
+// of base (nil for a full Reset Query) and returning the resulting VRP set
+func (c *Client) readPduStream(conn net.Conn, base []VRP) (vrps []VRP, sessionID uint16, serial uint32, err error) {
+	vrps = append([]VRP{}, base...)
+
+	header := make([]byte, 8)
+	for {
+		if _, err := readFull(conn, header); err != nil {
+			return nil, 0, 0, fmt.Errorf("RTR read PDU header: %v", err)
+		}
+
+		pduType := header[1]
+		pduSessionID := binary.BigEndian.Uint16(header[2:4])
+		length := binary.BigEndian.Uint32(header[4:8])
+		if length < 8 {
+			return nil, 0, 0, fmt.Errorf("RTR PDU length %d shorter than header", length)
+		}
+
+		body := make([]byte, length-8)
+		if len(body) > 0 {
+			if _, err := readFull(conn, body); err != nil {
+				return nil, 0, 0, fmt.Errorf("RTR read PDU body: %v", err)
+			}
+		}
+
+		switch pduType {
+		case pduCacheResp:
+			sessionID = pduSessionID
+
+		case pduIPv4Prefix:
+			vrp, announce := parseIPv4Prefix(body)
+			vrps = applyVrp(vrps, vrp, announce)
+
+		case pduIPv6Prefix:
+			vrp, announce := parseIPv6Prefix(body)
+			vrps = applyVrp(vrps, vrp, announce)
+
+		case pduCacheReset:
+			return nil, 0, 0, errCacheReset
+
+		case pduErrorReport:
+			return nil, 0, 0, fmt.Errorf("RTR error report: %s", string(body))
+
+		case pduEndOfData:
+			serial = binary.BigEndian.Uint32(body[0:4])
+			return vrps, sessionID, serial, nil
+		}
+	}
+}
+
+func applyVrp(vrps []VRP, vrp VRP, announce bool) []VRP {
+	if announce {
+		for _, existing := range vrps {
+			if existing.key() == vrp.key() {
+				return vrps
+			}
+		}
+		return append(vrps, vrp)
+	}
+
+	out := vrps[:0]
+	for _, existing := range vrps {
+		if existing.key() != vrp.key() {
+			out = append(out, existing)
+		}
+	}
+	return out
+}
+
+func readFull(conn net.Conn, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := conn.Read(buf[total:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// parseIPv4Prefix parses the payload of an IPv4 Prefix PDU (RFC 8210 section 5.6)
+func parseIPv4Prefix(body []byte) (vrp VRP, announce bool) {
+	announce = body[0]&1 == 1
+	prefixLen := body[1]
+	maxLen := body[2]
+	addr := net.IP(body[4:8])
+	asn := binary.BigEndian.Uint32(body[8:12])
+
+	return VRP{
+		Prefix:    fmt.Sprintf("%s/%d", addr.String(), prefixLen),
+		MaxLength: maxLen,
+		Asn:       asn,
+	}, announce
+}
+
+// parseIPv6Prefix parses the payload of an IPv6 Prefix PDU (RFC 8210 section 5.8)
+func parseIPv6Prefix(body []byte) (vrp VRP, announce bool) {
+	announce = body[0]&1 == 1
+	prefixLen := body[1]
+	maxLen := body[2]
+	addr := net.IP(body[4:20])
+	asn := binary.BigEndian.Uint32(body[20:24])
+
+	return VRP{
+		Prefix:    fmt.Sprintf("%s/%d", addr.String(), prefixLen),
+		MaxLength: maxLen,
+		Asn:       asn,
+	}, announce
+}
+
+// Covers reports whether the client's VRP set contains a ROA that
+// authorizes asn to originate addr (a bare IPv4 or IPv6 address, no
+// "/len" suffix) at prefixLen, per RFC 6811 section 2: the ROA's own
+// registered prefix must cover addr, and prefixLen must fall between the
+// ROA's registered length and its max length
+func (c *Client) Covers(asn uint32, addr string, prefixLen uint8) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, vrp := range c.Vrps {
+		if vrp.Asn != asn {
+			continue
+		}
+
+		_, vrpNet, err := net.ParseCIDR(vrp.Prefix)
+		if err != nil {
+			continue
+		}
+
+		registeredLen, _ := vrpNet.Mask.Size()
+		if prefixLen < uint8(registeredLen) || prefixLen > vrp.MaxLength {
+			continue
+		}
+
+		if vrpNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,122 @@
+package rtr
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+func ipv4PrefixBody(announce bool, prefixLen, maxLen uint8, addr string, asn uint32) []byte {
+	body := make([]byte, 12)
+	if announce {
+		body[0] = 1
+	}
+	body[1] = prefixLen
+	body[2] = maxLen
+	copy(body[4:8], net.ParseIP(addr).To4())
+	binary.BigEndian.PutUint32(body[8:12], asn)
+	return body
+}
+
+func ipv6PrefixBody(announce bool, prefixLen, maxLen uint8, addr string, asn uint32) []byte {
+	body := make([]byte, 24)
+	if announce {
+		body[0] = 1
+	}
+	body[1] = prefixLen
+	body[2] = maxLen
+	copy(body[4:20], net.ParseIP(addr).To16())
+	binary.BigEndian.PutUint32(body[20:24], asn)
+	return body
+}
+
+func TestParseIPv4Prefix(t *testing.T) {
+	vrp, announce := parseIPv4Prefix(ipv4PrefixBody(true, 24, 24, "192.0.2.0", 65000))
+	if !announce {
+		t.Fatalf("expected announce=true")
+	}
+	if vrp.Prefix != "192.0.2.0/24" || vrp.MaxLength != 24 || vrp.Asn != 65000 {
+		t.Fatalf("unexpected VRP: %+v", vrp)
+	}
+}
+
+func TestParseIPv4PrefixWithdraw(t *testing.T) {
+	_, announce := parseIPv4Prefix(ipv4PrefixBody(false, 24, 24, "192.0.2.0", 65000))
+	if announce {
+		t.Fatalf("expected announce=false")
+	}
+}
+
+func TestParseIPv6Prefix(t *testing.T) {
+	vrp, announce := parseIPv6Prefix(ipv6PrefixBody(true, 32, 48, "2001:db8::", 65001))
+	if !announce {
+		t.Fatalf("expected announce=true")
+	}
+	if vrp.Prefix != "2001:db8::/32" || vrp.MaxLength != 48 || vrp.Asn != 65001 {
+		t.Fatalf("unexpected VRP: %+v", vrp)
+	}
+}
+
+func TestApplyVrpAnnounceIsIdempotent(t *testing.T) {
+	vrp := VRP{Prefix: "192.0.2.0/24", MaxLength: 24, Asn: 65000}
+	vrps := applyVrp(nil, vrp, true)
+	vrps = applyVrp(vrps, vrp, true)
+	if len(vrps) != 1 {
+		t.Fatalf("expected 1 VRP after duplicate announce, got %d", len(vrps))
+	}
+}
+
+func TestApplyVrpWithdrawRemoves(t *testing.T) {
+	vrp := VRP{Prefix: "192.0.2.0/24", MaxLength: 24, Asn: 65000}
+	vrps := applyVrp(nil, vrp, true)
+	vrps = applyVrp(vrps, vrp, false)
+	if len(vrps) != 0 {
+		t.Fatalf("expected 0 VRPs after withdraw, got %d", len(vrps))
+	}
+}
+
+func TestClientCovers(t *testing.T) {
+	// A ROA for 192.0.2.0/24 with max length /26, per RFC 8210; callers
+	// pass the bare address of the prefix being checked, not a CIDR string
+	client := &Client{Vrps: []VRP{{Prefix: "192.0.2.0/24", MaxLength: 26, Asn: 65000}}}
+
+	if !client.Covers(65000, "192.0.2.0", 24) {
+		t.Errorf("expected coverage at the ROA's own prefix length")
+	}
+	if !client.Covers(65000, "192.0.2.0", 26) {
+		t.Errorf("expected coverage up to max length")
+	}
+	if client.Covers(65000, "192.0.2.0", 23) {
+		t.Errorf("expected no coverage below the ROA's registered length")
+	}
+	if client.Covers(65000, "192.0.2.0", 27) {
+		t.Errorf("expected no coverage beyond max length")
+	}
+	if client.Covers(65001, "192.0.2.0", 24) {
+		t.Errorf("expected no coverage for a different origin ASN")
+	}
+}
+
+func TestClientCoversMoreSpecificThanRegisteredLength(t *testing.T) {
+	// A ROA registered for 198.51.100.0/22 max /24 must cover a /24
+	// origination at an address within that /22, even though the /24's own
+	// CIDR string never equals the ROA's registered "/22" string
+	client := &Client{Vrps: []VRP{{Prefix: "198.51.100.0/22", MaxLength: 24, Asn: 65000}}}
+
+	if !client.Covers(65000, "198.51.100.0", 24) {
+		t.Errorf("expected a /24 within the ROA's /22 to be covered")
+	}
+	if !client.Covers(65000, "198.51.103.0", 24) {
+		t.Errorf("expected a /24 at the top of the ROA's /22 to be covered")
+	}
+}
+
+func TestClientCoversRequiresNetworkContainment(t *testing.T) {
+	// A prefix length inside the ROA's [24, 26] range is not enough on its
+	// own: the address must also fall within the ROA's registered network
+	client := &Client{Vrps: []VRP{{Prefix: "192.0.2.0/24", MaxLength: 26, Asn: 65000}}}
+
+	if client.Covers(65000, "203.0.113.0", 24) {
+		t.Errorf("expected no coverage for an address outside the ROA's network")
+	}
+}
@@ -0,0 +1,30 @@
+package rtr
+
+import (
+	"fmt"
+	"net"
+)
+
+// ValidateOriginated checks that every prefix in prefixes (in CIDR
+// notation) is covered by a VRP authorizing asn to originate it, returning
+// an error describing the first invalid prefix it finds. It's intended to
+// be called against the operator's own Config.Prefixes at config-load
+// time, so a misconfigured ROA is caught before bcg generates a config
+// that will be RPKI-invalid at upstreams.
+func (c *Client) ValidateOriginated(asn uint32, prefixes []string) error {
+	for _, prefix := range prefixes {
+		ip, ipNet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			return fmt.Errorf("%s is not a valid prefix in CIDR notation", prefix)
+		}
+
+		prefixLen, _ := ipNet.Mask.Size()
+		addr := ip.Mask(ipNet.Mask).String()
+
+		if !c.Covers(asn, addr, uint8(prefixLen)) {
+			return fmt.Errorf("%s is not covered by a ROA authorizing AS%d to originate it", prefix, asn)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/natesales/bcg/irr"
+	"github.com/natesales/bcg/peeringdb"
+	"github.com/natesales/bcg/rtr"
+)
+
+// rpkiCachePath is where RPKIProcessor's shared rtr.Client persists its VRP
+// set between runs, distinct from -rtr-cache (the cache for the one-shot
+// roa4/roa6 embedding client in main.go)
+const rpkiCachePath = "/var/lib/bcg/rtr-verify-cache.json"
+
+// PeeringDBProcessor fills in a peer's AS-Set from PeeringDB when one isn't
+// already known, so downstream processors have something to expand
+type PeeringDBProcessor struct{}
+
+// Name implements Processor
+func (PeeringDBProcessor) Name() string { return "PeeringDB" }
+
+// Process implements Processor
+func (PeeringDBProcessor) Process(ctx *Context) error {
+	if ctx.AsSet != "" {
+		return nil
+	}
+
+	data, err := peeringdb.GetData(ctx.Asn)
+	if err != nil {
+		return fmt.Errorf("PeeringDB lookup for AS%d: %v", ctx.Asn, err)
+	}
+
+	ctx.AsSet = data.AsSet
+	return nil
+}
+
+// IRRProcessor expands the peer's AS-Set into advertised prefixes via the
+// IRR and records whether each one it finds resolves to a route object
+type IRRProcessor struct {
+	Fetcher *irr.Fetcher
+}
+
+// Name implements Processor
+func (IRRProcessor) Name() string { return "IRR" }
+
+// Process implements Processor
+func (p IRRProcessor) Process(ctx *Context) error {
+	if ctx.AsSet == "" {
+		return fmt.Errorf("AS%d has no AS-Set to expand", ctx.Asn)
+	}
+
+	prefixes4, err := p.Fetcher.GetPrefixFilter(ctx.AsSet, 4, ctx.IrrDb)
+	if err != nil {
+		return fmt.Errorf("IRR IPv4 expansion for AS%d: %v", ctx.Asn, err)
+	}
+	prefixes6, err := p.Fetcher.GetPrefixFilter(ctx.AsSet, 6, ctx.IrrDb)
+	if err != nil {
+		return fmt.Errorf("IRR IPv6 expansion for AS%d: %v", ctx.Asn, err)
+	}
+
+	ctx.Prefixes4 = prefixes4
+	ctx.Prefixes6 = prefixes6
+
+	for _, prefix := range append(append([]string{}, prefixes4...), prefixes6...) {
+		// A prefix returned by the AS-Set expansion is, by definition, backed
+		// by a route:/route6: object in the IRR it was expanded from
+		ctx.Status.Prefixes = append(ctx.Status.Prefixes, PrefixStatus{
+			Prefix:   strings.TrimSpace(prefix),
+			IrrValid: true,
+		})
+	}
+
+	return nil
+}
+
+// RPKIProcessor validates each prefix discovered by earlier processors
+// against the ROAs held by an RTR cache server, sharing a single cached
+// rtr.Client across every peer it verifies (see DefaultChain) instead of
+// opening a new session and re-downloading the full VRP set per peer
+type RPKIProcessor struct {
+	mu     sync.Mutex
+	client *rtr.Client
+}
+
+// Name implements Processor
+func (*RPKIProcessor) Name() string { return "RPKI" }
+
+// Process implements Processor
+func (p *RPKIProcessor) Process(ctx *Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client == nil {
+		p.client = rtr.NewClient(ctx.RtrAddr)
+		if err := p.client.LoadCache(rpkiCachePath); err != nil {
+			log.Infof("No usable RTR verify cache at %s: %v", rpkiCachePath, err)
+		}
+	}
+
+	// After the first call this is an incremental Serial Query against the
+	// already-synced client, not a full Reset Query
+	if err := p.client.Refresh(); err != nil {
+		return fmt.Errorf("RTR refresh against %s: %v", ctx.RtrAddr, err)
+	}
+	if err := p.client.SaveCache(rpkiCachePath); err != nil {
+		log.Warnf("Save RTR verify cache to %s: %v", rpkiCachePath, err)
+	}
+
+	for i, pfx := range ctx.Status.Prefixes {
+		prefix, prefixLen, err := splitPrefix(pfx.Prefix)
+		if err != nil {
+			ctx.Status.Prefixes[i].Reason = err.Error()
+			continue
+		}
+
+		ctx.Status.Prefixes[i].RoaValid = p.client.Covers(ctx.Asn, prefix, prefixLen)
+		if !ctx.Status.Prefixes[i].RoaValid {
+			ctx.Status.Prefixes[i].Reason = fmt.Sprintf("no ROA covers AS%d for %s", ctx.Asn, pfx.Prefix)
+		}
+	}
+
+	return nil
+}
+
+// splitPrefix splits a "a.b.c.d/n" string into its address and length parts
+func splitPrefix(prefix string) (string, uint8, error) {
+	parts := strings.Split(prefix, "/")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("%s is not in CIDR notation", prefix)
+	}
+
+	var length uint8
+	if _, err := fmt.Sscanf(parts[1], "%d", &length); err != nil {
+		return "", 0, fmt.Errorf("%s has an invalid prefix length: %v", prefix, err)
+	}
+
+	return parts[0], length, nil
+}
+
+// DefaultChain is the standard PeeringDB -> IRR -> RPKI verification
+// pipeline, sharing a single cached Fetcher and a single cached rtr.Client
+// across every peer it verifies
+func DefaultChain() Chain {
+	cache, err := irr.NewCache(irr.DefaultCacheDir, irr.DefaultCacheTTL)
+	if err != nil {
+		cache = nil // fall back to uncached lookups rather than failing verification entirely
+	}
+
+	return Chain{
+		PeeringDBProcessor{},
+		IRRProcessor{Fetcher: irr.NewFetcher(cache)},
+		&RPKIProcessor{},
+	}
+}
@@ -0,0 +1,117 @@
+// Package verify runs a chain of Processors over each configured peer to
+// cross-check its advertised prefixes against PeeringDB, the IRR, and RPKI
+// ROAs before bcg trusts them in a generated filter.
+package verify
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PrefixStatus is the verification outcome for a single advertised prefix
+type PrefixStatus struct {
+	Prefix   string `json:"prefix"`
+	RoaValid bool   `json:"roa_valid"`
+	IrrValid bool   `json:"irr_valid"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// PeerStatus is the aggregate verification result for a peer, exposed to
+// both the render templates and the /statusz endpoint
+type PeerStatus struct {
+	PeerName     string         `json:"peer_name"`
+	Asn          uint32         `json:"asn"`
+	LastVerified time.Time      `json:"last_verified"`
+	Prefixes     []PrefixStatus `json:"prefixes"`
+	Passed       bool           `json:"passed"`
+}
+
+// Context is threaded through a Processor chain for a single peer
+type Context struct {
+	PeerName string
+	Asn      uint32
+	AsSet    string
+	IrrDb    string
+	RtrAddr  string
+
+	Prefixes4 []string
+	Prefixes6 []string
+	Status    PeerStatus
+}
+
+// Processor is a single stage in the verification pipeline. Processors run
+// in order and mutate the shared Context, so later stages (e.g. RPKI) can
+// rely on the prefixes earlier stages (e.g. IRR) discovered.
+type Processor interface {
+	// Name identifies the processor for logging and status reporting
+	Name() string
+	// Process runs this stage of verification against ctx
+	Process(ctx *Context) error
+}
+
+// Chain is an ordered list of Processors run for every peer
+type Chain []Processor
+
+// Run executes every Processor in the chain against ctx in order, stopping
+// early if a processor returns an error
+func (c Chain) Run(ctx *Context) error {
+	for _, p := range c {
+		log.Infof("Running %s verification for AS%d", p.Name(), ctx.Asn)
+		if err := p.Process(ctx); err != nil {
+			return err
+		}
+	}
+
+	ctx.Status.LastVerified = time.Now()
+	ctx.Status.Passed = true
+	for _, pfx := range ctx.Status.Prefixes {
+		if !pfx.RoaValid || !pfx.IrrValid {
+			ctx.Status.Passed = false
+			break
+		}
+	}
+
+	return nil
+}
+
+// Store holds the latest verification status for every peer, guarded for
+// concurrent access from the generator and the /statusz HTTP handler
+type Store struct {
+	mu       sync.RWMutex
+	statuses map[string]PeerStatus
+}
+
+// NewStore creates an empty Store
+func NewStore() *Store {
+	return &Store{statuses: make(map[string]PeerStatus)}
+}
+
+// Set records the latest status for a peer
+func (s *Store) Set(status PeerStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[status.PeerName] = status
+}
+
+// Get returns the latest status for a peer, and whether one was found
+func (s *Store) Get(peerName string) (PeerStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status, ok := s.statuses[peerName]
+	return status, ok
+}
+
+// StatuszHandler serves the current verification status of every peer as JSON
+func (s *Store) StatuszHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.statuses); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}